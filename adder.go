@@ -15,13 +15,16 @@
 package adder
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 	"reflect"
-	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -32,24 +35,65 @@ var durationType = reflect.TypeOf(time.Duration(0))
 // Adder manages configuration loaded from YAML files with optional environment
 // variable overrides. Use [New] to create an instance, or use the package-level
 // functions which operate on a default instance.
+//
+// All methods are safe for concurrent use. Setters may be called while other
+// goroutines read, but a load in progress uses the settings as they were when
+// it started.
 type Adder struct {
-	configFile   string
-	configName   string
-	configType   string
-	configPaths  []string
-	envReplacer  *strings.Replacer
-	autoEnv      bool
-	envBindings  map[string]string
-	configValues map[string]any
+	mu sync.RWMutex // guards all fields except the load and mask state below
+
+	configFile       string
+	configName       string
+	configType       string
+	configPaths      []string
+	configOptional   bool
+	envReplacer      *strings.Replacer
+	envPrefix        string
+	autoEnv          bool
+	allowUnprefixed  bool
+	expandWithPrefix bool
+	allowEmptyEnv    bool
+	fileEnvSuffix    bool
+	noInterpolation  bool
+	rawInterpolation bool
+	envBindings      map[string]envBinding // keyed by lower-cased key
+	resolvers        map[string]Resolver
+	sources          []configSource
+	documentMode     DocumentMode
+	docSelectorKey   string
+	docSelectorValue string
+
+	configValues  map[string]any
+	loadOptional  bool // optional mode of the last successful load, reused by reloads
+	origins       map[string]Position
+	templates     map[string]template // strings with key references, by lower-cased key
+	watchedFiles  []fileStamp
+	onChange      []func(Event)
+	onKeyChange   []keyChangeHandler
+	watchInterval time.Duration
+	stopWatch     chan struct{}
+
+	loadMu          sync.Mutex            // serializes ReadInConfig
+	loadedFiles     []fileStamp           // files read by the load in progress
+	loadedOrigins   map[string]Position   // lower-cased key -> position, for the load in progress
+	loadedTemplates map[string]template   // strings with key references, for the load in progress
+	loadedNodeFiles map[*yaml.Node]string // roots spliced in by !include and !include_dir
+
+	recordMu   sync.Mutex             // guards state recorded by Unmarshal, which only holds mu for reading
+	maskRules  map[string]maskRule    // mask tags seen by Unmarshal, keyed by maskKey
+	provenance map[string]Explanation // lower-cased key -> where Unmarshal got its value
 }
 
 // New returns a new Adder instance with empty configuration.
 func New() *Adder {
-	return &Adder{
+	a := &Adder{
 		configPaths:  []string{},
-		envBindings:  make(map[string]string),
+		envBindings:  make(map[string]envBinding),
+		resolvers:    make(map[string]Resolver),
 		configValues: make(map[string]any),
 	}
+	a.registerBuiltinResolvers()
+	return a
 }
 
 var defaultAdder = New()
@@ -60,6 +104,8 @@ func SetConfigFile(path string) { defaultAdder.SetConfigFile(path) }
 // SetConfigFile sets the exact config file path to use, bypassing the
 // name/type/path search. The file is read directly by [Adder.ReadInConfig].
 func (a *Adder) SetConfigFile(path string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	a.configFile = path
 }
 
@@ -68,6 +114,8 @@ func SetConfigName(name string) { defaultAdder.SetConfigName(name) }
 
 // SetConfigName sets the config filename without extension (e.g. "application").
 func (a *Adder) SetConfigName(name string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	a.configName = name
 }
 
@@ -76,6 +124,8 @@ func SetConfigType(typ string) { defaultAdder.SetConfigType(typ) }
 
 // SetConfigType sets the config file format. Supported values: "yaml", "yml".
 func (a *Adder) SetConfigType(typ string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	a.configType = strings.ToLower(typ)
 }
 
@@ -83,20 +133,101 @@ func (a *Adder) SetConfigType(typ string) {
 func AddConfigPath(path string) { defaultAdder.AddConfigPath(path) }
 
 // AddConfigPath adds a directory to the list of paths to search for the config file.
-// Paths are searched in the order they are added.
+// Paths are searched in the order they are added. A leading ~ and environment
+// variables such as $HOME or ${APP_DIR} are expanded when searching.
 func (a *Adder) AddConfigPath(path string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	a.configPaths = append(a.configPaths, path)
 }
 
+// SetConfigOptional calls [Adder.SetConfigOptional] on the default instance.
+func SetConfigOptional(optional bool) { defaultAdder.SetConfigOptional(optional) }
+
+// SetConfigOptional makes a missing config file a success rather than an
+// [ErrConfigNotFound] error, for deployments where every value comes from the
+// environment. [Adder.ReadInConfig] then loads only the other sources, if any.
+// A config name must still be set, and a file that exists but cannot be read
+// or parsed is still an error.
+func (a *Adder) SetConfigOptional(optional bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.configOptional = optional
+}
+
 // SetEnvKeyReplacer calls [Adder.SetEnvKeyReplacer] on the default instance.
 func SetEnvKeyReplacer(r *strings.Replacer) { defaultAdder.SetEnvKeyReplacer(r) }
 
 // SetEnvKeyReplacer sets a [strings.Replacer] for mapping config keys to environment
 // variable names. For example, strings.NewReplacer(".", "_") maps "http.port" to "HTTP_PORT".
 func (a *Adder) SetEnvKeyReplacer(r *strings.Replacer) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	a.envReplacer = r
 }
 
+// SetEnvPrefix calls [Adder.SetEnvPrefix] on the default instance.
+func SetEnvPrefix(prefix string) { defaultAdder.SetEnvPrefix(prefix) }
+
+// SetEnvPrefix sets a prefix for environment variables looked up by
+// [Adder.AutomaticEnv]. The prefix is upper-cased and joined with an underscore,
+// so with prefix "myapp" the key "server.port" maps to MYAPP_SERVER_PORT.
+// Variables named explicitly with [Adder.BindEnv] are not prefixed.
+func (a *Adder) SetEnvPrefix(prefix string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.envPrefix = strings.TrimSuffix(strings.ToUpper(prefix), "_")
+}
+
+// AllowUnprefixedEnv calls [Adder.AllowUnprefixedEnv] on the default instance.
+func AllowUnprefixedEnv(allow bool) { defaultAdder.AllowUnprefixedEnv(allow) }
+
+// AllowUnprefixedEnv makes environment lookups fall back to the unprefixed
+// name when the prefixed variable is not set. This eases migration after
+// [Adder.SetEnvPrefix] has been introduced.
+func (a *Adder) AllowUnprefixedEnv(allow bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.allowUnprefixed = allow
+}
+
+// ExpandEnvWithPrefix calls [Adder.ExpandEnvWithPrefix] on the default instance.
+func ExpandEnvWithPrefix(enable bool) { defaultAdder.ExpandEnvWithPrefix(enable) }
+
+// ExpandEnvWithPrefix applies the prefix set by [Adder.SetEnvPrefix] to ${VAR}
+// references in config files, so ${DB_HOST} reads MYAPP_DB_HOST. It must be
+// called before [Adder.ReadInConfig].
+func (a *Adder) ExpandEnvWithPrefix(enable bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expandWithPrefix = enable
+}
+
+// SetInterpolation calls [Adder.SetInterpolation] on the default instance.
+func SetInterpolation(enabled bool) { defaultAdder.SetInterpolation(enabled) }
+
+// SetInterpolation turns ${VAR} expansion in config files on or off. It is on
+// by default. When off, config values are used verbatim, including any $${...}
+// escapes. To keep interpolation on but write a literal ${...}, use $${...}.
+func (a *Adder) SetInterpolation(enabled bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.noInterpolation = !enabled
+}
+
+// SetRawInterpolation calls [Adder.SetRawInterpolation] on the default instance.
+func SetRawInterpolation(enabled bool) { defaultAdder.SetRawInterpolation(enabled) }
+
+// SetRawInterpolation restores the legacy behavior of expanding ${VAR} in the
+// raw file contents before parsing. By default, references are expanded in
+// parsed string values, so substituted text is always a string and cannot
+// inject new keys, and references inside comments are ignored.
+func (a *Adder) SetRawInterpolation(enabled bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rawInterpolation = enabled
+}
+
 // AutomaticEnv calls [Adder.AutomaticEnv] on the default instance.
 func AutomaticEnv() { defaultAdder.AutomaticEnv() }
 
@@ -104,18 +235,102 @@ func AutomaticEnv() { defaultAdder.AutomaticEnv() }
 // [Adder.Unmarshal] checks for an environment variable for each config key before
 // using the value from the config file. Use [Adder.SetEnvKeyReplacer] to control how
 // config keys are mapped to environment variable names.
+//
+// Slice elements and map entries are addressed by index or map key, so with a
+// dot-to-underscore replacer "servers.0.host" maps to SERVERS_0_HOST and
+// "upstreams.billing" maps to UPSTREAMS_BILLING. Only elements and entries
+// already present in the config file can be overridden this way.
 func (a *Adder) AutomaticEnv() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	a.autoEnv = true
 }
 
+// AllowEmptyEnv calls [Adder.AllowEmptyEnv] on the default instance.
+func AllowEmptyEnv(allow bool) { defaultAdder.AllowEmptyEnv(allow) }
+
+// AllowEmptyEnv controls whether environment variables that are set but empty
+// override config values. By default an empty variable is treated as unset.
+// When enabled, an empty variable clears string fields and causes
+// [Adder.Unmarshal] to return an error for numeric, bool and duration fields.
+func (a *Adder) AllowEmptyEnv(allow bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.allowEmptyEnv = allow
+}
+
+// EnableFileEnvSuffix calls [Adder.EnableFileEnvSuffix] on the default instance.
+func EnableFileEnvSuffix() { defaultAdder.EnableFileEnvSuffix() }
+
+// EnableFileEnvSuffix enables the Docker/Kubernetes secret convention: when an
+// environment variable such as DB_PASSWORD is not set but DB_PASSWORD_FILE is,
+// the value is read from the file it names, with trailing newlines removed.
+// This applies to both [Adder.AutomaticEnv] and [Adder.BindEnv] lookups.
+// Errors name the file but never include its contents.
+func (a *Adder) EnableFileEnvSuffix() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.fileEnvSuffix = true
+}
+
+// DocumentMode controls how config files containing several YAML documents
+// (separated by "---") are loaded.
+type DocumentMode int
+
+const (
+	// FirstDocument loads only the first document. This is the default.
+	FirstDocument DocumentMode = iota
+	// MergeDocuments deep-merges all documents in order, so later documents
+	// override earlier ones.
+	MergeDocuments
+)
+
+// SetDocumentMode calls [Adder.SetDocumentMode] on the default instance.
+func SetDocumentMode(mode DocumentMode) { defaultAdder.SetDocumentMode(mode) }
+
+// SetDocumentMode sets how multi-document YAML files are loaded. See [DocumentMode].
+func (a *Adder) SetDocumentMode(mode DocumentMode) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.documentMode = mode
+}
+
+// SetDocumentSelector calls [Adder.SetDocumentSelector] on the default instance.
+func SetDocumentSelector(key, value string) { defaultAdder.SetDocumentSelector(key, value) }
+
+// SetDocumentSelector merges only the documents whose discriminator key matches
+// value, letting one file carry overrides for every environment:
+//
+//	server:
+//	  port: 8080
+//	---
+//	profile: prod
+//	server:
+//	  port: 80
+//
+// With SetDocumentSelector("profile", "prod") both documents are merged; with any
+// other value only the first is used. Documents without the key always apply, and
+// a list value matches if any element does. The key may be a dotted path. The
+// selector implies [MergeDocuments]; an empty key removes it.
+func (a *Adder) SetDocumentSelector(key, value string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.docSelectorKey = key
+	a.docSelectorValue = value
+}
+
 // BindEnv calls [Adder.BindEnv] on the default instance.
 func BindEnv(key string, envVar string) error { return defaultAdder.BindEnv(key, envVar) }
 
 // BindEnv explicitly binds a config key to a specific environment variable.
 // The key uses dot notation for nested fields (e.g. "db.url").
-// Explicit bindings take precedence over [Adder.AutomaticEnv].
+// Explicit bindings take precedence over [Adder.AutomaticEnv]. Slice elements
+// are addressed by index (e.g. "servers.0.host"). A binding for a map entry
+// (e.g. "upstreams.billing") also adds the entry when it is not in the config file.
 func (a *Adder) BindEnv(key string, envVar string) error {
-	a.envBindings[strings.ToLower(key)] = envVar
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.envBindings[strings.ToLower(key)] = envBinding{key: key, envVar: envVar}
 	return nil
 }
 
@@ -125,70 +340,173 @@ func ReadInConfig() error { return defaultAdder.ReadInConfig() }
 // ReadInConfig searches the configured paths for the config file and loads it.
 // Struct field matching is case-insensitive, so YAML keys like "baseURL", "baseUrl",
 // and "baseurl" all match the same struct field. Map keys preserve their original casing.
-// Either [Adder.SetConfigFile] or [Adder.SetConfigName]/[Adder.SetConfigType]/[Adder.AddConfigPath] must be called before this.
+// Either [Adder.SetConfigFile] or [Adder.SetConfigName]/[Adder.SetConfigType]/[Adder.AddConfigPath] must be called before this,
+// unless only extra sources such as [Adder.AddConfigDir] or [Adder.AddKeyPerFileDir] are used.
+//
+// YAML files may use the !include, !include_dir and !env tags to splice in other
+// files, directories and environment variables.
+//
+// Sources added with [Adder.AddConfigDir] and [Adder.AddKeyPerFileDir] are deep-merged
+// on top of the config file in the order they were added. Each call replaces previously loaded values.
 func (a *Adder) ReadInConfig() error {
-	var configFile string
+	_, err := a.load(false)
+	return err
+}
 
-	if a.configFile != "" {
-		if _, err := os.Stat(a.configFile); err != nil {
-			return fmt.Errorf("config file not found: %s", a.configFile)
-		}
-		configFile = a.configFile
-		if a.configType == "" {
-			ext := strings.TrimPrefix(filepath.Ext(configFile), ".")
-			if ext != "" {
-				a.configType = strings.ToLower(ext)
-			} else {
-				a.configType = "yaml"
+// ReadInConfigOptional calls [Adder.ReadInConfigOptional] on the default instance.
+func ReadInConfigOptional() error { return defaultAdder.ReadInConfigOptional() }
+
+// ReadInConfigOptional is like [Adder.ReadInConfig], but a missing config file
+// is not an error, as if [Adder.SetConfigOptional] were enabled for this call.
+// Reloads triggered by [Adder.WatchConfig] or [Adder.ReloadOnSignal] keep this
+// behavior until [Adder.ReadInConfig] succeeds again.
+func (a *Adder) ReadInConfigOptional() error {
+	_, err := a.load(true)
+	return err
+}
+
+// load reads every layer and, on success, replaces the current values. It
+// returns the keys that changed compared to the previous values. With optional
+// set, a missing config file is treated as empty.
+func (a *Adder) load(optional bool) ([]Change, error) {
+	a.loadMu.Lock()
+	defer a.loadMu.Unlock()
+
+	values, err := a.loadValues(optional)
+	if err != nil {
+		a.mu.Lock()
+		a.watchedFiles = mergeStamps(a.loadedFiles, a.watchedFiles)
+		a.mu.Unlock()
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	changes := a.diffValues(a.configValues, a.origins, values, a.loadedOrigins)
+	a.configValues = values
+	a.loadOptional = optional
+	a.origins = a.loadedOrigins
+	a.templates = a.loadedTemplates
+	a.watchedFiles = a.loadedFiles
+	return changes, nil
+}
+
+// loadValues reads the config file and every source and merges them. It holds
+// a read lock so that settings cannot change halfway through a load.
+func (a *Adder) loadValues(optional bool) (map[string]any, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	a.loadedFiles = nil
+	a.loadedOrigins = make(map[string]Position)
+	a.loadedNodeFiles = make(map[*yaml.Node]string)
+	values := make(map[string]any)
+
+	if a.configFile != "" || a.configName != "" || len(a.sources) == 0 {
+		configFile, configType, err := a.findConfigFile()
+		var notFound *ConfigNotFoundError
+		switch {
+		case errors.As(err, &notFound) && (optional || a.configOptional):
+			// Watch the candidates so that a file created later is picked up.
+			for _, path := range notFound.SearchedPaths {
+				a.trackFile(path)
+			}
+		case err != nil:
+			return nil, err
+		default:
+			if values, err = a.readConfigFile(configFile, configType); err != nil {
+				return nil, err
 			}
 		}
-	} else {
-		if a.configName == "" {
-			return fmt.Errorf("config name not set")
+	}
+
+	for _, src := range a.sources {
+		srcValues, err := src.load(a)
+		if err != nil {
+			return nil, err
 		}
-		for _, path := range a.configPaths {
-			for _, ext := range configExtensions(a.configType) {
-				candidate := filepath.Join(path, a.configName+"."+ext)
-				if _, err := os.Stat(candidate); err == nil {
-					configFile = candidate
-					break
-				}
-			}
-			if configFile != "" {
-				break
+		mergeValues(values, srcValues)
+	}
+
+	a.loadedTemplates = make(map[string]template)
+	extractTemplates("", values, a.loadedTemplates)
+	return values, nil
+}
+
+// trackFile records a file or directory read by the current load so that
+// [Adder.WatchConfig] can watch it. It must be called before the file is read:
+// the stamp taken here is what later changes are compared against, so a write
+// that lands after the read is still noticed.
+func (a *Adder) trackFile(path string) {
+	a.loadedFiles = append(a.loadedFiles, statFile(path))
+}
+
+// findConfigFile returns the config file to read and its type. A type set with
+// [Adder.SetConfigType] wins; otherwise it is taken from the file extension.
+func (a *Adder) findConfigFile() (string, string, error) {
+	if a.configFile != "" {
+		if _, err := os.Stat(a.configFile); errors.Is(err, fs.ErrNotExist) {
+			return "", "", &ConfigNotFoundError{Name: a.configFile, SearchedPaths: []string{a.configFile}}
+		} else if err != nil {
+			return "", "", err
+		}
+		configType := a.configType
+		if configType == "" {
+			configType = strings.ToLower(strings.TrimPrefix(filepath.Ext(a.configFile), "."))
+			if configType == "" {
+				configType = "yaml"
 			}
 		}
-		if configFile == "" {
-			return fmt.Errorf("config file not found: %s.%s", a.configName, a.configType)
+		return a.configFile, configType, nil
+	}
+
+	if a.configName == "" {
+		return "", "", ErrConfigNameNotSet
+	}
+	notFound := &ConfigNotFoundError{Name: a.configName}
+	for _, path := range a.configPaths {
+		path = expandPath(path)
+		for _, ext := range configExtensions(a.configType) {
+			candidate := filepath.Join(path, a.configName+"."+ext)
+			_, err := os.Stat(candidate)
+			if err == nil {
+				return candidate, a.configType, nil
+			}
+			// Anything but a missing file (e.g. permission denied) is reported
+			// rather than hidden behind ConfigNotFoundError.
+			if !errors.Is(err, fs.ErrNotExist) {
+				return "", "", err
+			}
+			notFound.SearchedPaths = append(notFound.SearchedPaths, candidate)
 		}
 	}
+	return "", "", notFound
+}
 
-	data, err := os.ReadFile(configFile)
+// readConfigFile reads and parses a single config file, expanding ${VAR}
+// references unless interpolation is disabled.
+func (a *Adder) readConfigFile(path, configType string) (map[string]any, error) {
+	if !supportedConfigType(configType) {
+		return nil, &UnsupportedTypeError{Type: configType}
+	}
+
+	docs, err := a.loadYAMLDocs(path, nil)
 	if err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
 	}
 
-	// Expand ${VAR} references in the raw config (bare $VAR is intentionally not expanded)
-	data = []byte(expandEnvBraceOnly(string(data)))
+	values, err := a.decodeDocuments(docs, path)
+	if err != nil {
+		return nil, err
+	}
 
-	switch a.configType {
-	case "yaml", "yml":
-		if err := yaml.Unmarshal(data, &a.configValues); err != nil {
-			return fmt.Errorf("failed to parse yaml: %w", err)
+	if !a.noInterpolation {
+		if err := a.interpolateValues(values, "", !a.rawInterpolation); err != nil {
+			return nil, fmt.Errorf("failed to expand config file: %w", err)
 		}
-	default:
-		return fmt.Errorf("unsupported config type: %s", a.configType)
 	}
 
-	return nil
-}
-
-var envBraceRe = regexp.MustCompile(`\$\{([^}]+)\}`)
-
-func expandEnvBraceOnly(s string) string {
-	return envBraceRe.ReplaceAllStringFunc(s, func(match string) string {
-		return os.Getenv(match[2 : len(match)-1])
-	})
+	return values, nil
 }
 
 // Unmarshal calls [Adder.Unmarshal] on the default instance.
@@ -197,8 +515,11 @@ func Unmarshal(v any) error { return defaultAdder.Unmarshal(v) }
 // Unmarshal decodes the loaded configuration into a struct. The target must be
 // a non-nil pointer to a struct. Fields are matched by lowercase name or by
 // the "mapstructure" struct tag. Environment variable overrides are applied
-// during unmarshalling.
+// during unmarshalling. References to other config keys, such as
+// ${server.host}, are resolved here so that they see those overrides.
 func (a *Adder) Unmarshal(v any) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	return a.unmarshalWithPath(a.configValues, v, "")
 }
 
@@ -233,11 +554,20 @@ func (a *Adder) unmarshalWithPath(data map[string]any, v any, prefix string) err
 			fullKey = prefix + "." + fieldName
 		}
 
+		if rule, ok := parseMaskTag(field.Tag.Get("mask")); ok {
+			a.recordMaskRule(fullKey, rule)
+		}
+
 		// Check for env override
-		if envVal := a.getEnvValue(fullKey); envVal != "" {
-			if err := setFieldFromString(fieldValue, envVal, fullKey); err != nil {
-				return err
+		envVal, ok, err := a.getEnvValue(fullKey)
+		if err != nil {
+			return err
+		}
+		if ok && isScalarKind(fieldValue.Kind()) {
+			if err := setFieldFromString(fieldValue, envVal.value, fullKey); err != nil {
+				return envVal.wrapErr(err, fullKey)
 			}
+			a.recordSource(fullKey, &envVal)
 			continue
 		}
 
@@ -261,24 +591,118 @@ func (a *Adder) unmarshalWithPath(data map[string]any, v any, prefix string) err
 	return nil
 }
 
-func (a *Adder) getEnvValue(key string) string {
-	lowerKey := strings.ToLower(key)
+// envValue is an environment override and where it came from.
+type envValue struct {
+	value   string
+	name    string // variable that supplied the value
+	file    string // set when the value was read from the file named by a <NAME>_FILE variable
+	binding bool   // set when the variable was named with BindEnv
+}
+
+// wrapErr annotates an error from applying e to keyPath. Values read from
+// secret files are kept out of the message.
+func (e envValue) wrapErr(err error, keyPath string) error {
+	if e.file == "" {
+		return fmt.Errorf("env %s: %w", e.name, err)
+	}
+	msg := fmt.Sprintf("env %s: invalid value at %s read from %s", e.name, keyPath, e.file)
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return fmt.Errorf("%s: %w", msg, numErr.Err)
+	}
+	return errors.New(msg)
+}
 
-	// Check explicit bindings first
-	if envVar, ok := a.envBindings[lowerKey]; ok {
-		return os.Getenv(envVar)
+// redactErr keeps a value read from a secret file out of err when the value
+// reached keyPath through a key reference. secret may be nil.
+func redactErr(err error, secret *envValue, keyPath string) error {
+	if err == nil || secret == nil {
+		return err
 	}
+	return secret.wrapErr(err, keyPath)
+}
+
+// getEnvValue returns the environment override for key, if any.
+func (a *Adder) getEnvValue(key string) (envValue, bool, error) {
+	names, binding := a.envCandidates(key)
+	v, ok, err := a.lookupFirst(names)
+	v.binding = binding
+	return v, ok, err
+}
 
-	// Check automatic env
+// envCandidates returns the environment variables that may override key, in
+// order, and whether they come from an explicit binding. Explicit bindings take
+// precedence over automatic env.
+func (a *Adder) envCandidates(key string) ([]string, bool) {
+	if b, ok := a.envBindings[strings.ToLower(key)]; ok {
+		return []string{b.envVar}, true
+	}
 	if a.autoEnv {
 		envKey := strings.ToUpper(key)
 		if a.envReplacer != nil {
 			envKey = a.envReplacer.Replace(envKey)
 		}
-		return os.Getenv(envKey)
+		return a.envNames(envKey), false
+	}
+	return nil, false
+}
+
+// envNames returns the environment variable names to try for name, in order,
+// taking the env prefix into account.
+func (a *Adder) envNames(name string) []string {
+	if a.envPrefix == "" {
+		return []string{name}
+	}
+	names := []string{a.envPrefix + "_" + name}
+	if a.allowUnprefixed {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (a *Adder) lookupFirst(names []string) (envValue, bool, error) {
+	for _, name := range names {
+		if v, ok := a.lookupEnv(name); ok {
+			return envValue{value: v, name: name}, true, nil
+		}
+		if !a.fileEnvSuffix {
+			continue
+		}
+		fileVar := name + "_FILE"
+		path := os.Getenv(fileVar)
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return envValue{}, false, fmt.Errorf("env %s: failed to read secret file: %w", fileVar, err)
+		}
+		v := strings.TrimRight(string(data), "\r\n")
+		if v != "" || a.allowEmptyEnv {
+			return envValue{value: v, name: fileVar, file: path}, true, nil
+		}
+	}
+	return envValue{}, false, nil
+}
+
+// lookupEnv reports whether name is set, treating empty values as unset
+// unless [Adder.AllowEmptyEnv] is enabled.
+func (a *Adder) lookupEnv(name string) (string, bool) {
+	v, ok := os.LookupEnv(name)
+	if !a.allowEmptyEnv && v == "" {
+		return "", false
 	}
+	return v, ok
+}
 
-	return ""
+func isScalarKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
 }
 
 func (a *Adder) setFieldValue(field reflect.Value, value any, keyPath string) error {
@@ -286,11 +710,72 @@ func (a *Adder) setFieldValue(field reflect.Value, value any, keyPath string) er
 		return nil
 	}
 
+	var secret *envValue
+	if s, ok := value.(string); ok {
+		resolved, src, err := a.resolveString(keyPath, s, []string{keyPath})
+		if err != nil {
+			return a.withPosition(keyPath, fmt.Errorf("invalid reference at %s: %w", keyPath, err))
+		}
+		value, secret = resolved, src
+	}
+
+	if isScalarKind(field.Kind()) {
+		a.recordSource(keyPath, nil)
+		return a.withPosition(keyPath, redactErr(setScalarField(field, value, keyPath), secret, keyPath))
+	}
+
 	switch field.Kind() {
 	case reflect.Struct:
 		if m, ok := value.(map[string]any); ok {
 			return a.unmarshalWithPath(m, field.Addr().Interface(), keyPath)
 		}
+	case reflect.Map:
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		mapType := field.Type()
+		if mapType.Key().Kind() != reflect.String || mapType.Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map type %s at %s: only map[string]string is supported", mapType, keyPath)
+		}
+		newMap := reflect.MakeMap(mapType)
+		for k, v := range m {
+			s := fmt.Sprintf("%v", v)
+			envVal, ok, err := a.getEnvValue(keyPath + "." + k)
+			if err != nil {
+				return err
+			}
+			if ok {
+				s = envVal.value
+				a.recordSource(keyPath+"."+k, &envVal)
+				newMap.SetMapIndex(reflect.ValueOf(k), reflect.ValueOf(s))
+				continue
+			}
+			if str, ok := v.(string); ok {
+				resolved, _, err := a.resolveString(keyPath+"."+k, str, []string{keyPath + "." + k})
+				if err != nil {
+					return a.withPosition(keyPath+"."+k, fmt.Errorf("invalid reference at %s.%s: %w", keyPath, k, err))
+				}
+				s = resolved
+			}
+			a.recordSource(keyPath+"."+k, nil)
+			newMap.SetMapIndex(reflect.ValueOf(k), reflect.ValueOf(s))
+		}
+		if err := a.applyMapBindings(newMap, m, keyPath); err != nil {
+			return err
+		}
+		field.Set(newMap)
+	case reflect.Slice:
+		return a.setSliceField(field, value, keyPath)
+	}
+
+	return nil
+}
+
+// setScalarField stores a value decoded from a config file in a string,
+// integer or bool field.
+func setScalarField(field reflect.Value, value any, keyPath string) error {
+	switch field.Kind() {
 	case reflect.String:
 		if s, ok := value.(string); ok {
 			field.SetString(s)
@@ -301,57 +786,97 @@ func (a *Adder) setFieldValue(field reflect.Value, value any, keyPath string) er
 		}
 		switch v := value.(type) {
 		case int:
-			field.SetInt(int64(v))
+			return setIntField(field, int64(v), keyPath)
 		case int64:
-			field.SetInt(v)
+			return setIntField(field, v, keyPath)
 		case float64:
-			field.SetInt(int64(v))
+			return setIntFromFloat(field, v, keyPath)
+		case string:
+			return setFieldFromString(field, v, keyPath)
 		}
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		switch v := value.(type) {
 		case int:
 			if v >= 0 {
-				field.SetUint(uint64(v))
+				return setUintField(field, uint64(v), keyPath)
 			}
 		case int64:
 			if v >= 0 {
-				field.SetUint(uint64(v))
+				return setUintField(field, uint64(v), keyPath)
 			}
 		case uint:
-			field.SetUint(uint64(v))
+			return setUintField(field, uint64(v), keyPath)
 		case uint64:
-			field.SetUint(v)
+			return setUintField(field, v, keyPath)
 		case float64:
 			if v >= 0 {
-				field.SetUint(uint64(v))
+				return setUintFromFloat(field, v, keyPath)
 			}
+		case string:
+			return setFieldFromString(field, v, keyPath)
 		}
 	case reflect.Bool:
-		if b, ok := value.(bool); ok {
-			field.SetBool(b)
+		switch v := value.(type) {
+		case bool:
+			field.SetBool(v)
+		case string:
+			return setFieldFromString(field, v, keyPath)
 		}
-	case reflect.Map:
-		m, ok := value.(map[string]any)
-		if !ok {
-			return nil
+	}
+	return nil
+}
+
+// withPosition prefixes err with the file position of keyPath, if known, e.g.
+// "application.yaml:42:11: invalid duration at server.timeout: ...".
+func (a *Adder) withPosition(keyPath string, err error) error {
+	if err == nil {
+		return nil
+	}
+	pos, ok := a.origins[strings.ToLower(keyPath)]
+	if !ok {
+		return err
+	}
+	return fmt.Errorf("%s: %w", pos, err)
+}
+
+// envBinding is an explicit binding made with BindEnv. key keeps the casing it
+// was bound with, so that map entries it adds keep their original name.
+type envBinding struct {
+	key    string
+	envVar string
+}
+
+// applyMapBindings adds entries for explicit bindings directly under keyPath
+// (e.g. "upstreams.billing") whose key is not already present in the config.
+func (a *Adder) applyMapBindings(newMap reflect.Value, m map[string]any, keyPath string) error {
+	prefix := strings.ToLower(keyPath) + "."
+	for lowerKey, b := range a.envBindings {
+		rest, ok := strings.CutPrefix(lowerKey, prefix)
+		if !ok || rest == "" || strings.Contains(rest, ".") {
+			continue
 		}
-		mapType := field.Type()
-		if mapType.Key().Kind() != reflect.String || mapType.Elem().Kind() != reflect.String {
-			return fmt.Errorf("unsupported map type %s at %s: only map[string]string is supported", mapType, keyPath)
+		name := b.key[strings.LastIndex(b.key, ".")+1:]
+		if _, exists := caseInsensitiveLookup(m, name); exists {
+			continue
 		}
-		newMap := reflect.MakeMap(mapType)
-		for k, v := range m {
-			newMap.SetMapIndex(reflect.ValueOf(k), reflect.ValueOf(fmt.Sprintf("%v", v)))
+		envVal, ok, err := a.lookupFirst([]string{b.envVar})
+		if err != nil {
+			return err
+		}
+		if ok {
+			envVal.binding = true
+			newMap.SetMapIndex(reflect.ValueOf(name), reflect.ValueOf(envVal.value))
+			a.recordSource(keyPath+"."+name, &envVal)
 		}
-		field.Set(newMap)
-	case reflect.Slice:
-		return a.setSliceField(field, value, keyPath)
 	}
-
 	return nil
 }
 
 func setFieldFromString(field reflect.Value, value string, keyPath string) error {
+	if value == "" && field.Kind() != reflect.String {
+		return fmt.Errorf("empty value at %s: cannot convert to %s", keyPath, field.Type())
+	}
+
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(value)
@@ -364,23 +889,66 @@ func setFieldFromString(field reflect.Value, value string, keyPath string) error
 			field.SetInt(int64(d))
 			return nil
 		}
-		i, err := strconv.ParseInt(value, 10, 64)
+		i, err := parseInt(value, field.Type().Bits())
 		if err != nil {
-			return err
+			return fmt.Errorf("invalid integer at %s: %w", keyPath, err)
 		}
 		field.SetInt(i)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		u, err := strconv.ParseUint(value, 10, 64)
+		u, err := parseUint(value, field.Type().Bits())
 		if err != nil {
-			return err
+			return fmt.Errorf("invalid integer at %s: %w", keyPath, err)
 		}
 		field.SetUint(u)
 	case reflect.Bool:
-		field.SetBool(value == "true" || value == "1")
+		b, err := parseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid bool at %s: %w", keyPath, err)
+		}
+		field.SetBool(b)
 	}
 	return nil
 }
 
+func setIntField(field reflect.Value, v int64, keyPath string) error {
+	if field.OverflowInt(v) {
+		return fmt.Errorf("value %d out of range for %s at %s", v, field.Type(), keyPath)
+	}
+	field.SetInt(v)
+	return nil
+}
+
+func setUintField(field reflect.Value, v uint64, keyPath string) error {
+	if field.OverflowUint(v) {
+		return fmt.Errorf("value %d out of range for %s at %s", v, field.Type(), keyPath)
+	}
+	field.SetUint(v)
+	return nil
+}
+
+// setIntFromFloat stores a YAML float such as 1e3 in an integer field. The
+// value must be a whole number that fits the field.
+func setIntFromFloat(field reflect.Value, v float64, keyPath string) error {
+	if v != math.Trunc(v) {
+		return fmt.Errorf("value %v is not an integer for %s at %s", v, field.Type(), keyPath)
+	}
+	if v < math.MinInt64 || v >= math.MaxInt64 {
+		return fmt.Errorf("value %v out of range for %s at %s", v, field.Type(), keyPath)
+	}
+	return setIntField(field, int64(v), keyPath)
+}
+
+// setUintFromFloat is the unsigned counterpart of setIntFromFloat.
+func setUintFromFloat(field reflect.Value, v float64, keyPath string) error {
+	if v != math.Trunc(v) {
+		return fmt.Errorf("value %v is not an integer for %s at %s", v, field.Type(), keyPath)
+	}
+	if v >= math.MaxUint64 {
+		return fmt.Errorf("value %v out of range for %s at %s", v, field.Type(), keyPath)
+	}
+	return setUintField(field, uint64(v), keyPath)
+}
+
 func setDurationField(field reflect.Value, value any, keyPath string) error {
 	switch v := value.(type) {
 	case string:
@@ -425,6 +993,34 @@ func (a *Adder) setSliceField(field reflect.Value, value any, keyPath string) er
 
 	for i, item := range slice {
 		elem := newSlice.Index(i)
+		elemKey := keyPath + "." + strconv.Itoa(i)
+
+		// Scalar elements can be overridden individually (e.g. "hosts.0" -> HOSTS_0);
+		// struct elements are handled field by field in unmarshalWithPath.
+		if elemType.Kind() != reflect.Struct {
+			envVal, ok, err := a.getEnvValue(elemKey)
+			if err != nil {
+				return err
+			}
+			if ok {
+				if err := setFieldFromString(elem, envVal.value, elemKey); err != nil {
+					return envVal.wrapErr(err, elemKey)
+				}
+				a.recordSource(elemKey, &envVal)
+				continue
+			}
+			a.recordSource(elemKey, nil)
+		}
+
+		var secret *envValue
+		if s, ok := item.(string); ok {
+			resolved, src, err := a.resolveString(elemKey, s, []string{elemKey})
+			if err != nil {
+				return a.withPosition(elemKey, fmt.Errorf("invalid reference at %s: %w", elemKey, err))
+			}
+			item, secret = resolved, src
+		}
+
 		switch elemType.Kind() {
 		case reflect.String:
 			if s, ok := item.(string); ok {
@@ -432,8 +1028,8 @@ func (a *Adder) setSliceField(field reflect.Value, value any, keyPath string) er
 			}
 		case reflect.Int, reflect.Int64:
 			if elemType == durationType {
-				if err := setDurationField(elem, item, keyPath); err != nil {
-					return err
+				if err := setDurationField(elem, item, elemKey); err != nil {
+					return a.withPosition(elemKey, redactErr(err, secret, elemKey))
 				}
 				continue
 			}
@@ -441,11 +1037,17 @@ func (a *Adder) setSliceField(field reflect.Value, value any, keyPath string) er
 			case int:
 				elem.SetInt(int64(v))
 			case float64:
-				elem.SetInt(int64(v))
+				if err := setIntFromFloat(elem, v, elemKey); err != nil {
+					return a.withPosition(elemKey, err)
+				}
+			case string:
+				if err := setFieldFromString(elem, v, elemKey); err != nil {
+					return a.withPosition(elemKey, redactErr(err, secret, elemKey))
+				}
 			}
 		case reflect.Struct:
 			if m, ok := item.(map[string]any); ok {
-				if err := a.unmarshalWithPath(m, elem.Addr().Interface(), keyPath); err != nil {
+				if err := a.unmarshalWithPath(m, elem.Addr().Interface(), elemKey); err != nil {
 					return err
 				}
 			}
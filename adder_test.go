@@ -1,9 +1,12 @@
 package adder
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -742,6 +745,712 @@ backoffs:
 	}, cfg.Backoffs)
 }
 
+func TestAutomaticEnvOverrideSliceElement(t *testing.T) {
+	type server struct {
+		Host string
+		Port int
+	}
+	type config struct {
+		Servers []server
+		Hosts   []string
+	}
+
+	a := newTestAdder(t, `
+servers:
+  - host: a.internal
+    port: 80
+  - host: b.internal
+    port: 81
+hosts:
+  - one
+  - two
+`)
+	a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	a.AutomaticEnv()
+	t.Setenv("SERVERS_1_HOST", "c.internal")
+	t.Setenv("SERVERS_0_PORT", "8080")
+	t.Setenv("HOSTS_1", "three")
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, []server{{Host: "a.internal", Port: 8080}, {Host: "c.internal", Port: 81}}, cfg.Servers)
+	assert.Equal(t, []string{"one", "three"}, cfg.Hosts)
+}
+
+func TestBindEnvOverrideSliceElement(t *testing.T) {
+	type server struct {
+		Host string
+	}
+	type config struct {
+		Servers []server
+	}
+
+	a := newTestAdder(t, `
+servers:
+  - host: a.internal
+  - host: b.internal
+`)
+	require.NoError(t, a.BindEnv("servers.0.host", "PRIMARY_HOST"))
+	t.Setenv("PRIMARY_HOST", "primary.internal")
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, []server{{Host: "primary.internal"}, {Host: "b.internal"}}, cfg.Servers)
+}
+
+func TestEnvOverrideMapEntry(t *testing.T) {
+	type config struct {
+		Upstreams map[string]string
+	}
+
+	a := newTestAdder(t, `
+upstreams:
+  billing_url: http://billing.local
+  search_url: http://search.local
+`)
+	a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	a.AutomaticEnv()
+	require.NoError(t, a.BindEnv("upstreams.audit_url", "AUDIT_URL"))
+	t.Setenv("UPSTREAMS_BILLING_URL", "http://billing.prod")
+	t.Setenv("AUDIT_URL", "http://audit.prod")
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, map[string]string{
+		"billing_url": "http://billing.prod",
+		"search_url":  "http://search.local",
+		"audit_url":   "http://audit.prod",
+	}, cfg.Upstreams)
+}
+
+func TestEnvOverrideMapEntryKeepsCase(t *testing.T) {
+	type config struct {
+		Upstreams map[string]string
+	}
+
+	a := newTestAdder(t, "upstreams:\n  Search: http://search.local\n")
+	require.NoError(t, a.BindEnv("Upstreams.Billing", "BILLING_URL"))
+	require.NoError(t, a.BindEnv("upstreams.search", "SEARCH_URL"))
+	t.Setenv("BILLING_URL", "http://billing.prod")
+	t.Setenv("SEARCH_URL", "http://search.prod")
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, map[string]string{
+		"Billing": "http://billing.prod",
+		"Search":  "http://search.prod",
+	}, cfg.Upstreams)
+}
+
+func TestEnvPrefix(t *testing.T) {
+	t.Run("automatic env uses prefixed name", func(t *testing.T) {
+		a := newTestAdder(t, "http:\n  port: 8080\n")
+		a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		a.SetEnvPrefix("myapp")
+		a.AutomaticEnv()
+		t.Setenv("HTTP_PORT", "9090")
+		t.Setenv("MYAPP_HTTP_PORT", "9091")
+
+		var cfg testConfig
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, uint(9091), cfg.Http.Port)
+	})
+
+	t.Run("unprefixed name ignored by default", func(t *testing.T) {
+		a := newTestAdder(t, "http:\n  port: 8080\n")
+		a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		a.SetEnvPrefix("MYAPP_")
+		a.AutomaticEnv()
+		t.Setenv("HTTP_PORT", "9090")
+
+		var cfg testConfig
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, uint(8080), cfg.Http.Port)
+	})
+
+	t.Run("unprefixed fallback when allowed", func(t *testing.T) {
+		a := newTestAdder(t, "http:\n  port: 8080\nlog:\n  level: info\n")
+		a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		a.SetEnvPrefix("MYAPP")
+		a.AllowUnprefixedEnv(true)
+		a.AutomaticEnv()
+		t.Setenv("HTTP_PORT", "9090")
+		t.Setenv("LOG_LEVEL", "warn")
+		t.Setenv("MYAPP_LOG_LEVEL", "debug")
+
+		var cfg testConfig
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, uint(9090), cfg.Http.Port)
+		assert.Equal(t, "debug", cfg.Log.Level)
+	})
+
+	t.Run("bound env vars are not prefixed", func(t *testing.T) {
+		a := newTestAdder(t, "db:\n  url: postgres://from-config\n")
+		a.SetEnvPrefix("MYAPP")
+		require.NoError(t, a.BindEnv("db.url", "DATABASE_URL"))
+		t.Setenv("DATABASE_URL", "postgres://from-env")
+
+		var cfg testConfig
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "postgres://from-env", cfg.Db.URL)
+	})
+}
+
+func TestEnvPrefixExpansion(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "application.yaml"), []byte("db:\n  url: postgres://${DB_HOST}/${DB_NAME}\n"), 0o644))
+	t.Setenv("DB_HOST", "plain-host")
+	t.Setenv("MYAPP_DB_HOST", "prefixed-host")
+	t.Setenv("DB_NAME", "mydb")
+
+	a := New()
+	a.SetConfigName("application")
+	a.SetConfigType("yaml")
+	a.AddConfigPath(dir)
+	a.SetEnvPrefix("MYAPP")
+	a.AllowUnprefixedEnv(true)
+	a.ExpandEnvWithPrefix(true)
+	require.NoError(t, a.ReadInConfig())
+
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "postgres://prefixed-host/mydb", cfg.Db.URL)
+}
+
+func TestAllowEmptyEnv(t *testing.T) {
+	type config struct {
+		Feature struct {
+			Banner string
+		}
+		Http testHTTPConfig
+	}
+
+	t.Run("empty env ignored by default", func(t *testing.T) {
+		a := newTestAdder(t, "feature:\n  banner: hello\n")
+		a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		a.AutomaticEnv()
+		t.Setenv("FEATURE_BANNER", "")
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "hello", cfg.Feature.Banner)
+	})
+
+	t.Run("empty env clears string", func(t *testing.T) {
+		a := newTestAdder(t, "feature:\n  banner: hello\n")
+		a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		a.AutomaticEnv()
+		a.AllowEmptyEnv(true)
+		t.Setenv("FEATURE_BANNER", "")
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "", cfg.Feature.Banner)
+	})
+
+	t.Run("unset env still uses config", func(t *testing.T) {
+		a := newTestAdder(t, "feature:\n  banner: hello\n")
+		a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		a.AutomaticEnv()
+		a.AllowEmptyEnv(true)
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "hello", cfg.Feature.Banner)
+	})
+
+	t.Run("empty env errors for numeric field", func(t *testing.T) {
+		a := newTestAdder(t, "http:\n  port: 8080\n")
+		a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		a.AutomaticEnv()
+		a.AllowEmptyEnv(true)
+		t.Setenv("HTTP_PORT", "")
+
+		var cfg config
+		err := a.Unmarshal(&cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "empty value at http.port")
+	})
+
+	t.Run("empty bound env clears string", func(t *testing.T) {
+		a := newTestAdder(t, "feature:\n  banner: hello\n")
+		a.AllowEmptyEnv(true)
+		require.NoError(t, a.BindEnv("feature.banner", "BANNER"))
+		t.Setenv("BANNER", "")
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "", cfg.Feature.Banner)
+	})
+}
+
+func TestBoolEnvOverride(t *testing.T) {
+	type config struct {
+		Debug bool
+	}
+
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"true", true},
+		{"TRUE", true},
+		{"yes", true},
+		{"On", true},
+		{"1", true},
+		{"false", false},
+		{"No", false},
+		{"OFF", false},
+		{"0", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			a := newTestAdder(t, "debug: "+strconv.FormatBool(!tc.want)+"\n")
+			a.AutomaticEnv()
+			t.Setenv("DEBUG", tc.value)
+
+			var cfg config
+			require.NoError(t, a.Unmarshal(&cfg))
+			assert.Equal(t, tc.want, cfg.Debug)
+		})
+	}
+
+	t.Run("invalid value", func(t *testing.T) {
+		a := newTestAdder(t, "debug: true\n")
+		a.AutomaticEnv()
+		t.Setenv("DEBUG", "maybe")
+
+		var cfg config
+		err := a.Unmarshal(&cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid bool at debug")
+	})
+}
+
+func TestBoolFromYAMLString(t *testing.T) {
+	type config struct {
+		Enabled  bool
+		Disabled bool
+	}
+
+	a := newTestAdder(t, `
+enabled: "on"
+disabled: "No"
+`)
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.True(t, cfg.Enabled)
+	assert.False(t, cfg.Disabled)
+
+	a = newTestAdder(t, "enabled: \"sure\"\n")
+	err := a.Unmarshal(&cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid bool at enabled")
+}
+
+func TestIntegerEnvOverrideFormats(t *testing.T) {
+	type config struct {
+		Mode    uint32
+		MaxBody int64 `mapstructure:"max_body"`
+		Retries int8
+	}
+
+	a := newTestAdder(t, "mode: 420\nmax_body: 1024\nretries: 3\n")
+	a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	a.AutomaticEnv()
+	t.Setenv("MODE", "0o755")
+	t.Setenv("MAX_BODY", "1_000_000")
+	t.Setenv("RETRIES", "0x1F")
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, uint32(0o755), cfg.Mode)
+	assert.Equal(t, int64(1000000), cfg.MaxBody)
+	assert.Equal(t, int8(31), cfg.Retries)
+
+	t.Setenv("RETRIES", "300")
+	err := a.Unmarshal(&cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "env RETRIES: invalid integer at retries")
+	assert.Contains(t, err.Error(), "value out of range")
+}
+
+func TestIntegerFromYAML(t *testing.T) {
+	type config struct {
+		Port    uint16
+		Buffer  int
+		Retries int8
+	}
+
+	a := newTestAdder(t, "port: \"0x1F90\"\nbuffer: 64Ki\n")
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, uint16(8080), cfg.Port)
+	assert.Equal(t, 64<<10, cfg.Buffer)
+
+	a = newTestAdder(t, "retries: 1000\n")
+	err := a.Unmarshal(&cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "value 1000 out of range for int8 at retries")
+}
+
+func TestIntegerFromQuotedYAML(t *testing.T) {
+	type config struct {
+		Port    uint16
+		Retries int
+	}
+
+	a := newTestAdder(t, "port: \"8080\"\nretries: '1_000'\n")
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, uint16(8080), cfg.Port)
+	assert.Equal(t, 1000, cfg.Retries)
+
+	a = newTestAdder(t, "port: \"eighty\"\n")
+	err := a.Unmarshal(&cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid integer at port")
+}
+
+func TestIntegerFromYAMLFloat(t *testing.T) {
+	type config struct {
+		Port    uint16
+		Retries int8
+		Sizes   []int
+	}
+
+	a := newTestAdder(t, "port: 8.08e3\nretries: 1e1\nsizes: [1e3]\n")
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, uint16(8080), cfg.Port)
+	assert.Equal(t, int8(10), cfg.Retries)
+	assert.Equal(t, []int{1000}, cfg.Sizes)
+
+	tests := map[string]string{
+		"retries: 1e10\n":  "value 10000000000 out of range for int8 at retries",
+		"retries: 1e300\n": "value 1e+300 out of range for int8 at retries",
+		"retries: 1.5\n":   "value 1.5 is not an integer for int8 at retries",
+		"port: 1e10\n":     "value 10000000000 out of range for uint16 at port",
+		"port: 80.5\n":     "value 80.5 is not an integer for uint16 at port",
+		"sizes: [1e300]\n": "value 1e+300 out of range for int at sizes.0",
+	}
+	for content, want := range tests {
+		var cfg config
+		err := newTestAdder(t, content).Unmarshal(&cfg)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestFileEnvSuffix(t *testing.T) {
+	type config struct {
+		Db struct {
+			Password string
+			Port     int
+		}
+	}
+
+	writeSecret := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "secret")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("reads file when variable unset", func(t *testing.T) {
+		a := newTestAdder(t, "db:\n  password: from-config\n")
+		a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		a.AutomaticEnv()
+		a.EnableFileEnvSuffix()
+		t.Setenv("DB_PASSWORD_FILE", writeSecret(t, "s3cret\n"))
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "s3cret", cfg.Db.Password)
+	})
+
+	t.Run("variable takes precedence over file", func(t *testing.T) {
+		a := newTestAdder(t, "db:\n  password: from-config\n")
+		a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		a.AutomaticEnv()
+		a.EnableFileEnvSuffix()
+		t.Setenv("DB_PASSWORD", "direct")
+		t.Setenv("DB_PASSWORD_FILE", writeSecret(t, "s3cret\n"))
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "direct", cfg.Db.Password)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		a := newTestAdder(t, "db:\n  password: from-config\n")
+		a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		a.AutomaticEnv()
+		t.Setenv("DB_PASSWORD_FILE", writeSecret(t, "s3cret\n"))
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "from-config", cfg.Db.Password)
+	})
+
+	t.Run("works with bindings", func(t *testing.T) {
+		a := newTestAdder(t, "db:\n  password: from-config\n")
+		a.EnableFileEnvSuffix()
+		require.NoError(t, a.BindEnv("db.password", "POSTGRES_PASSWORD"))
+		t.Setenv("POSTGRES_PASSWORD_FILE", writeSecret(t, "bound\r\n"))
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "bound", cfg.Db.Password)
+	})
+
+	t.Run("missing file names the file", func(t *testing.T) {
+		a := newTestAdder(t, "db:\n  password: from-config\n")
+		a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		a.AutomaticEnv()
+		a.EnableFileEnvSuffix()
+		t.Setenv("DB_PASSWORD_FILE", "/nonexistent/secret")
+
+		var cfg config
+		err := a.Unmarshal(&cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "env DB_PASSWORD_FILE: failed to read secret file")
+		assert.Contains(t, err.Error(), "/nonexistent/secret")
+	})
+
+	t.Run("invalid value is not leaked", func(t *testing.T) {
+		a := newTestAdder(t, "db:\n  port: 5432\n")
+		a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		a.AutomaticEnv()
+		a.EnableFileEnvSuffix()
+		path := writeSecret(t, "topsecret\n")
+		t.Setenv("DB_PORT_FILE", path)
+
+		var cfg config
+		err := a.Unmarshal(&cfg)
+		require.Error(t, err)
+		assert.Equal(t, "env DB_PORT_FILE: invalid value at db.port read from "+path+": invalid syntax", err.Error())
+		assert.NotContains(t, err.Error(), "topsecret")
+	})
+
+	t.Run("invalid value is not leaked through a reference", func(t *testing.T) {
+		a := newTestAdder(t, "db:\n  password: from-config\n  port: ${db.password}\n")
+		a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		a.AutomaticEnv()
+		a.EnableFileEnvSuffix()
+		path := writeSecret(t, "topsecret\n")
+		t.Setenv("DB_PASSWORD_FILE", path)
+
+		var cfg config
+		err := a.Unmarshal(&cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "env DB_PASSWORD_FILE: invalid value at db.port read from "+path+": invalid syntax")
+		assert.NotContains(t, err.Error(), "topsecret")
+	})
+}
+
+func TestUnmarshalErrorPosition(t *testing.T) {
+	type config struct {
+		Server struct {
+			Timeout time.Duration
+		}
+		Ports []int
+		Debug bool
+	}
+
+	t.Run("duration", func(t *testing.T) {
+		a := newTestAdder(t, "server:\n  timeout: 5 sec\n")
+		var cfg config
+		err := a.Unmarshal(&cfg)
+		require.Error(t, err)
+		assert.Regexp(t, `application\.yaml:2:12: invalid duration at server\.timeout: .*"5 sec"`, err.Error())
+	})
+
+	t.Run("slice element", func(t *testing.T) {
+		a := newTestAdder(t, "ports:\n  - 80\n  - eighty\n")
+		var cfg config
+		err := a.Unmarshal(&cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "application.yaml:3:5: invalid integer at ports.1")
+	})
+
+	t.Run("env override has no position", func(t *testing.T) {
+		a := newTestAdder(t, "debug: true\n")
+		require.NoError(t, a.BindEnv("debug", "POSITION_DEBUG"))
+		t.Setenv("POSITION_DEBUG", "maybe")
+		var cfg config
+		err := a.Unmarshal(&cfg)
+		require.Error(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), "env POSITION_DEBUG: invalid bool at debug"), err.Error())
+	})
+}
+
+func TestReadInConfigParseErrorNamesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "application.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("http:\n  port: [8080\n"), 0o644))
+
+	a := New()
+	a.SetConfigFile(path)
+	err := a.ReadInConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), path+": failed to parse yaml")
+}
+
+func TestConfigOptional(t *testing.T) {
+	t.Run("set config optional", func(t *testing.T) {
+		a := New()
+		a.SetConfigName("application")
+		a.SetConfigType("yaml")
+		a.AddConfigPath(t.TempDir())
+		a.SetConfigOptional(true)
+		require.NoError(t, a.BindEnv("log.level", "OPTIONAL_LOG_LEVEL"))
+		t.Setenv("OPTIONAL_LOG_LEVEL", "warn")
+
+		require.NoError(t, a.ReadInConfig())
+		var cfg testConfig
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "warn", cfg.Log.Level)
+	})
+
+	t.Run("read in config optional", func(t *testing.T) {
+		a := New()
+		a.SetConfigFile(filepath.Join(t.TempDir(), "application.yaml"))
+
+		require.NoError(t, a.ReadInConfigOptional())
+		require.ErrorIs(t, a.ReadInConfig(), ErrConfigNotFound)
+	})
+
+	t.Run("sources still load", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"conf.d/log.yaml": "log:\n  level: debug\n"})
+
+		a := New()
+		a.SetConfigName("application")
+		a.SetConfigType("yaml")
+		a.AddConfigPath(dir)
+		a.AddConfigDir(filepath.Join(dir, "conf.d"))
+
+		require.ErrorIs(t, a.ReadInConfig(), ErrConfigNotFound)
+		require.NoError(t, a.ReadInConfigOptional())
+		var cfg testConfig
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "debug", cfg.Log.Level)
+	})
+
+	t.Run("parse errors are not ignored", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "application.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("log: [unclosed\n"), 0o644))
+
+		a := New()
+		a.SetConfigFile(path)
+		a.SetConfigOptional(true)
+
+		var parseErr *ParseError
+		require.ErrorAs(t, a.ReadInConfig(), &parseErr)
+	})
+
+	t.Run("stat errors are not ignored", func(t *testing.T) {
+		// A symlink loop fails with ELOOP, which stands in for errors such as
+		// permission denied that cannot be provoked when running as root.
+		dir := t.TempDir()
+		path := filepath.Join(dir, "application.yaml")
+		require.NoError(t, os.Symlink(path, path))
+
+		a := New()
+		a.SetConfigFile(path)
+		a.SetConfigOptional(true)
+		err := a.ReadInConfig()
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrConfigNotFound)
+
+		a = New()
+		a.SetConfigName("application")
+		a.SetConfigType("yaml")
+		a.AddConfigPath(dir)
+		err = a.ReadInConfigOptional()
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrConfigNotFound)
+	})
+
+	t.Run("name not set", func(t *testing.T) {
+		a := New()
+		a.SetConfigOptional(true)
+		a.AddConfigPath(t.TempDir())
+		require.ErrorIs(t, a.ReadInConfig(), ErrConfigNameNotSet)
+	})
+}
+
+func TestConcurrentUse(t *testing.T) {
+	a := newTestAdder(t, "log:\n  level: info\nhttp:\n  port: 8080\ndb:\n  url: postgres://localhost\n")
+	a.AutomaticEnv()
+	a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	t.Setenv("CONCURRENT_LEVEL", "debug")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				var cfg testConfig
+				assert.NoError(t, a.Unmarshal(&cfg))
+				assert.Equal(t, uint(8080), cfg.Http.Port)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				assert.NoError(t, a.ReadInConfig())
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				assert.NoError(t, a.BindEnv(fmt.Sprintf("extra.%d.%d", i, j), "UNUSED"))
+				a.SetEnvPrefix("")
+				a.AllowEmptyEnv(false)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.NoError(t, a.BindEnv("log.level", "CONCURRENT_LEVEL"))
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "debug", cfg.Log.Level)
+}
+
+func TestConcurrentUseDefaultInstance(t *testing.T) {
+	saved := defaultAdder
+	defaultAdder = New()
+	t.Cleanup(func() { defaultAdder = saved })
+
+	path := filepath.Join(t.TempDir(), "application.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: info\n"), 0o644))
+	SetConfigFile(path)
+	require.NoError(t, ReadInConfig())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			var cfg testConfig
+			assert.NoError(t, Unmarshal(&cfg))
+			assert.Equal(t, "info", cfg.Log.Level)
+		}()
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, BindEnv(fmt.Sprintf("db.url%d", i), "UNUSED"))
+			assert.NoError(t, ReadInConfig())
+		}(i)
+	}
+	wg.Wait()
+}
+
 func newTestAdder(t *testing.T, content string) *Adder {
 	t.Helper()
 	dir := t.TempDir()
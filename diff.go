@@ -0,0 +1,155 @@
+package adder
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change describes a config key whose value differs after a reload.
+//
+// Old and New are redacted for fields tagged with `mask`, but only once a
+// struct with that field has been passed to [Adder.Unmarshal]: the tags are
+// learned from those calls. Until then, changes report values in the clear.
+type Change struct {
+	// Key is the dotted key path, with list elements addressed by index
+	// (e.g. "servers.0.host").
+	Key string
+	// Old is the previous value, or nil if the key was added.
+	Old any
+	// New is the current value, or nil if the key was removed. Values are
+	// compared as loaded: env references are expanded, while references to
+	// other keys appear as written (e.g. "${db.host}").
+	New any
+	// Source is the file that supplied New, or Old for a removed key.
+	Source string
+}
+
+// ChangesUnder returns the changes whose key is prefix or lies beneath it,
+// compared case-insensitively. A trailing ".*" on prefix is ignored, so
+// "database" and "database.*" are equivalent.
+func (e Event) ChangesUnder(prefix string) []Change {
+	prefix = strings.ToLower(strings.TrimSuffix(prefix, ".*"))
+	var out []Change
+	for _, c := range e.Changes {
+		key := strings.ToLower(c.Key)
+		if prefix == "" || key == prefix || strings.HasPrefix(key, prefix+".") {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+type keyChangeHandler struct {
+	prefix string
+	fn     func([]Change)
+}
+
+// OnKeyChange calls [Adder.OnKeyChange] on the default instance.
+func OnKeyChange(prefix string, fn func([]Change)) { defaultAdder.OnKeyChange(prefix, fn) }
+
+// OnKeyChange registers a callback that runs after a successful reload only if
+// keys under prefix changed, e.g. OnKeyChange("database", reconnect). It
+// receives the matching changes, as returned by [Event.ChangesUnder].
+func (a *Adder) OnKeyChange(prefix string, fn func([]Change)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onKeyChange = append(a.onKeyChange, keyChangeHandler{prefix: prefix, fn: fn})
+}
+
+// diffValues compares two sets of loaded values leaf by leaf. Values of fields
+// tagged with `mask` in structs passed to [Adder.Unmarshal] are redacted.
+func (a *Adder) diffValues(oldValues map[string]any, oldOrigins map[string]Position, newValues map[string]any, newOrigins map[string]Position) []Change {
+	oldFlat := make(map[string]any)
+	newFlat := make(map[string]any)
+	flattenValues("", oldValues, oldFlat)
+	flattenValues("", newValues, newFlat)
+
+	var changes []Change
+	for key, newVal := range newFlat {
+		oldVal, existed := oldFlat[key]
+		if existed && reflect.DeepEqual(oldVal, newVal) {
+			continue
+		}
+		if !existed {
+			oldVal = nil
+		}
+		changes = append(changes, Change{Key: key, Old: oldVal, New: newVal, Source: newOrigins[strings.ToLower(key)].File})
+	}
+	for key, oldVal := range oldFlat {
+		if _, ok := newFlat[key]; !ok {
+			changes = append(changes, Change{Key: key, Old: oldVal, Source: oldOrigins[strings.ToLower(key)].File})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	for i := range changes {
+		if rule, ok := a.lookupMaskRule(changes[i].Key); ok {
+			changes[i].Old = maskValue(changes[i].Old, rule)
+			changes[i].New = maskValue(changes[i].New, rule)
+		}
+	}
+	return changes
+}
+
+// flattenValues stores every leaf of v in out under its dotted key path.
+func flattenValues(prefix string, v any, out map[string]any) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			flattenValues(join(k), item, out)
+		}
+	case []any:
+		for i, item := range v {
+			flattenValues(join(strconv.Itoa(i)), item, out)
+		}
+	default:
+		if prefix != "" {
+			out[prefix] = v
+		}
+	}
+}
+
+func (a *Adder) recordMaskRule(key string, rule maskRule) {
+	a.recordMu.Lock()
+	defer a.recordMu.Unlock()
+	if a.maskRules == nil {
+		a.maskRules = make(map[string]maskRule)
+	}
+	a.maskRules[maskKey(key)] = rule
+}
+
+func (a *Adder) lookupMaskRule(key string) (maskRule, bool) {
+	a.recordMu.Lock()
+	defer a.recordMu.Unlock()
+	rule, ok := a.maskRules[maskKey(key)]
+	return rule, ok
+}
+
+// maskKey normalizes a key path so that a mask tag seen on one list element
+// applies to all of them: "servers.0.password" becomes "servers.*.password".
+func maskKey(key string) string {
+	parts := strings.Split(strings.ToLower(key), ".")
+	for i, part := range parts {
+		if _, err := strconv.Atoi(part); err == nil {
+			parts[i] = "*"
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+func maskValue(v any, rule maskRule) any {
+	if v == nil {
+		return nil
+	}
+	return maskString(fmt.Sprintf("%v", v), rule)
+}
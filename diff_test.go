@@ -0,0 +1,149 @@
+package adder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadReportsChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "application.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: info\nhttp:\n  port: 8080\nold: 1\n"), 0o644))
+
+	_, events := newWatchedAdder(t, path)
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: debug\nhttp:\n  port: 8080\nnew: 2\n"), 0o644))
+
+	e := waitEvent(t, events)
+	require.NoError(t, e.Err)
+	abs, _ := filepath.Abs(path)
+	assert.Equal(t, []Change{
+		{Key: "log.level", Old: "info", New: "debug", Source: abs},
+		{Key: "new", Old: nil, New: 2, Source: abs},
+		{Key: "old", Old: 1, New: nil, Source: abs},
+	}, e.Changes)
+}
+
+func TestReloadChangeSourceFromLayer(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"application.yaml":  "db:\n  host: localhost\n  port: 5432\n",
+		"conf.d/10-db.yaml": "db:\n  port: 5433\n",
+	})
+
+	a := New()
+	a.SetConfigFile(filepath.Join(dir, "application.yaml"))
+	a.AddConfigDir(filepath.Join(dir, "conf.d"))
+	_, err := a.load(false)
+	require.NoError(t, err)
+
+	writeFiles(t, dir, map[string]string{"conf.d/10-db.yaml": "db:\n  port: 6000\n"})
+	changes, err := a.load(false)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "db.port", changes[0].Key)
+	assert.Equal(t, 5433, changes[0].Old)
+	assert.Equal(t, 6000, changes[0].New)
+	assert.Equal(t, "10-db.yaml", filepath.Base(changes[0].Source))
+}
+
+func TestReloadChangesShowLiteralValues(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"application.yaml": "token: ${TOKEN}\n",
+		"secrets/label":    "{{ ${labels.x} }}",
+	})
+	t.Setenv("TOKEN", "a${b}")
+
+	a := New()
+	a.SetConfigFile(filepath.Join(dir, "application.yaml"))
+	a.AddKeyPerFileDir(filepath.Join(dir, "secrets"))
+	_, err := a.load(false)
+	require.NoError(t, err)
+
+	writeFiles(t, dir, map[string]string{"secrets/label": "{{ ${labels.y} }}"})
+	t.Setenv("TOKEN", "c$${d}")
+	changes, err := a.load(false)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "label", changes[0].Key)
+	assert.Equal(t, "{{ ${labels.x} }}", changes[0].Old)
+	assert.Equal(t, "{{ ${labels.y} }}", changes[0].New)
+	assert.Equal(t, "token", changes[1].Key)
+	assert.Equal(t, "a${b}", changes[1].Old)
+	assert.Equal(t, "c$${d}", changes[1].New)
+}
+
+func TestReloadChangesListIndexes(t *testing.T) {
+	a := New()
+	changes := a.diffValues(
+		map[string]any{"servers": []any{map[string]any{"host": "a"}, map[string]any{"host": "b"}}}, nil,
+		map[string]any{"servers": []any{map[string]any{"host": "a"}}}, nil,
+	)
+	assert.Equal(t, []Change{{Key: "servers.1.host", Old: "b"}}, changes)
+}
+
+func TestReloadChangesMasked(t *testing.T) {
+	type config struct {
+		DB struct {
+			Password string `mask:"true"`
+			User     string
+		}
+	}
+
+	a := newTestAdder(t, "db:\n  password: hunter2\n  user: app\n")
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+
+	changes := a.diffValues(
+		map[string]any{"db": map[string]any{"password": "hunter2", "user": "app"}}, nil,
+		map[string]any{"db": map[string]any{"password": "s3cret", "user": "root"}}, nil,
+	)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "db.password", changes[0].Key)
+	assert.NotContains(t, changes[0].Old, "hunter2")
+	assert.NotContains(t, changes[0].New, "s3cret")
+	assert.Equal(t, "root", changes[1].New)
+}
+
+func TestReloadChangesNotMaskedBeforeUnmarshal(t *testing.T) {
+	a := newTestAdder(t, "db:\n  password: hunter2\n")
+
+	changes := a.diffValues(
+		map[string]any{"db": map[string]any{"password": "hunter2"}}, nil,
+		map[string]any{"db": map[string]any{"password": "s3cret"}}, nil,
+	)
+	assert.Equal(t, []Change{{Key: "db.password", Old: "hunter2", New: "s3cret"}}, changes)
+}
+
+func TestOnKeyChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "application.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("database:\n  host: a\nlog:\n  level: info\n"), 0o644))
+
+	a, events := newWatchedAdder(t, path)
+	httpChanges := make(chan []Change, 10)
+	a.OnKeyChange("http", func(c []Change) { httpChanges <- c })
+	dbChanges := make(chan []Change, 10)
+	a.OnKeyChange("database.*", func(c []Change) { dbChanges <- c })
+
+	require.NoError(t, os.WriteFile(path, []byte("database:\n  host: b\nlog:\n  level: debug\n"), 0o644))
+	e := waitEvent(t, events)
+	require.NoError(t, e.Err)
+
+	select {
+	case c := <-dbChanges:
+		assert.Equal(t, []Change{{Key: "database.host", Old: "a", New: "b", Source: e.Changes[0].Source}}, c)
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnKeyChange callback was not called")
+	}
+	assert.Empty(t, httpChanges)
+}
+
+func TestEventChangesUnder(t *testing.T) {
+	e := Event{Changes: []Change{{Key: "db.host"}, {Key: "dbx.port"}, {Key: "DB"}}}
+	assert.Equal(t, []Change{{Key: "db.host"}, {Key: "DB"}}, e.ChangesUnder("db"))
+	assert.Len(t, e.ChangesUnder(""), 3)
+}
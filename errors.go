@@ -0,0 +1,58 @@
+package adder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrConfigNotFound is matched by [errors.Is] when no config file could be
+	// found. Use [errors.As] with a [*ConfigNotFoundError] for the details.
+	ErrConfigNotFound = errors.New("config file not found")
+
+	// ErrConfigNameNotSet is returned by [Adder.ReadInConfig] when neither a
+	// config file nor a config name has been set.
+	ErrConfigNameNotSet = errors.New("config name not set")
+)
+
+// ConfigNotFoundError is returned by [Adder.ReadInConfig] when the config file
+// does not exist. It matches [ErrConfigNotFound].
+type ConfigNotFoundError struct {
+	// Name is the config name set with [Adder.SetConfigName], or the path set
+	// with [Adder.SetConfigFile].
+	Name string
+	// SearchedPaths lists every candidate path that was tried, in order.
+	SearchedPaths []string
+}
+
+func (e *ConfigNotFoundError) Error() string {
+	if len(e.SearchedPaths) == 0 || (len(e.SearchedPaths) == 1 && e.SearchedPaths[0] == e.Name) {
+		return fmt.Sprintf("%s: %s", ErrConfigNotFound, e.Name)
+	}
+	return fmt.Sprintf("%s: %s (searched %s)", ErrConfigNotFound, e.Name, strings.Join(e.SearchedPaths, ", "))
+}
+
+func (e *ConfigNotFoundError) Unwrap() error { return ErrConfigNotFound }
+
+// ParseError is returned when a config file is not valid YAML.
+type ParseError struct {
+	Path string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: failed to parse yaml: %v", e.Path, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// UnsupportedTypeError is returned when the config type, set with
+// [Adder.SetConfigType] or taken from the file extension, is not supported.
+type UnsupportedTypeError struct {
+	Type string
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return fmt.Sprintf("unsupported config type: %s", e.Type)
+}
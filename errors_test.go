@@ -0,0 +1,86 @@
+package adder
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigNotFoundError(t *testing.T) {
+	t.Run("search paths", func(t *testing.T) {
+		dir1, dir2 := t.TempDir(), t.TempDir()
+		a := New()
+		a.SetConfigName("application")
+		a.SetConfigType("yaml")
+		a.AddConfigPath(dir1)
+		a.AddConfigPath(dir2)
+
+		err := a.ReadInConfig()
+		require.ErrorIs(t, err, ErrConfigNotFound)
+
+		var notFound *ConfigNotFoundError
+		require.ErrorAs(t, err, &notFound)
+		assert.Equal(t, "application", notFound.Name)
+		assert.Equal(t, []string{
+			filepath.Join(dir1, "application.yaml"),
+			filepath.Join(dir1, "application.yml"),
+			filepath.Join(dir2, "application.yaml"),
+			filepath.Join(dir2, "application.yml"),
+		}, notFound.SearchedPaths)
+		assert.Contains(t, err.Error(), filepath.Join(dir2, "application.yml"))
+	})
+
+	t.Run("config file", func(t *testing.T) {
+		a := New()
+		a.SetConfigFile("/nonexistent/config.yaml")
+
+		err := a.ReadInConfig()
+		require.ErrorIs(t, err, ErrConfigNotFound)
+		assert.EqualError(t, err, "config file not found: /nonexistent/config.yaml")
+
+		var notFound *ConfigNotFoundError
+		require.ErrorAs(t, err, &notFound)
+		assert.Equal(t, []string{"/nonexistent/config.yaml"}, notFound.SearchedPaths)
+	})
+
+	t.Run("name not set", func(t *testing.T) {
+		a := New()
+		a.AddConfigPath(t.TempDir())
+		assert.ErrorIs(t, a.ReadInConfig(), ErrConfigNameNotSet)
+	})
+}
+
+func TestParseError(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"application.yaml": "db: !include db.yaml\n",
+		"db.yaml":          "url: [unclosed\n",
+	})
+
+	a := New()
+	a.SetConfigFile(filepath.Join(dir, "application.yaml"))
+	err := a.ReadInConfig()
+
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, filepath.Join(dir, "db.yaml"), parseErr.Path)
+	assert.Contains(t, err.Error(), "failed to parse yaml")
+}
+
+func TestUnsupportedTypeError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "application.toml")
+	require.NoError(t, os.WriteFile(path, []byte("key = \"value\"\n"), 0o644))
+
+	a := New()
+	a.SetConfigFile(path)
+	err := a.ReadInConfig()
+
+	var typeErr *UnsupportedTypeError
+	require.True(t, errors.As(err, &typeErr))
+	assert.Equal(t, "toml", typeErr.Type)
+	assert.EqualError(t, err, "unsupported config type: toml")
+}
@@ -0,0 +1,171 @@
+package adder
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Position is a location in a config file. Line and Column are 1-based and
+// zero when unknown, as for values read by [Adder.AddKeyPerFileDir].
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// String returns the position as "file:line:col", or just the file name when
+// the line is unknown.
+func (p Position) String() string {
+	if p.Line == 0 {
+		return p.File
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// SourceKind identifies where a config value came from.
+type SourceKind int
+
+const (
+	// SourceNone means the key has no value.
+	SourceNone SourceKind = iota
+	// SourceFile is a value read from a config file or directory.
+	SourceFile
+	// SourceEnv is a value read from an environment variable by [Adder.AutomaticEnv].
+	SourceEnv
+	// SourceBinding is a value read from an environment variable named with [Adder.BindEnv].
+	SourceBinding
+)
+
+func (k SourceKind) String() string {
+	switch k {
+	case SourceFile:
+		return "file"
+	case SourceEnv:
+		return "env"
+	case SourceBinding:
+		return "binding"
+	}
+	return "none"
+}
+
+// Candidate is a source that was checked while resolving a key.
+type Candidate struct {
+	Kind SourceKind
+	// Name is the environment variable name, or the file position.
+	Name string
+	// Set reports whether the source had a value.
+	Set bool
+}
+
+// Explanation describes where the value of a config key came from.
+type Explanation struct {
+	Key    string
+	Source SourceKind
+	// Position is where the value was written, for SourceFile.
+	Position Position
+	// EnvVar is the variable that supplied the value, for SourceEnv and
+	// SourceBinding. It is the <NAME>_FILE variable when the value was read
+	// from a secret file (see [Adder.EnableFileEnvSuffix]).
+	EnvVar string
+	// Candidates lists every source checked, in order of precedence.
+	Candidates []Candidate
+}
+
+// String formats the explanation for logs, one candidate per line:
+//
+//	server.port: env MYAPP_SERVER_PORT
+//	  checked env MYAPP_SERVER_PORT (set)
+//	  checked file application.yaml:3:9 (set)
+func (e Explanation) String() string {
+	var b strings.Builder
+	switch e.Source {
+	case SourceFile:
+		fmt.Fprintf(&b, "%s: file %s", e.Key, e.Position)
+	case SourceEnv, SourceBinding:
+		fmt.Fprintf(&b, "%s: %s %s", e.Key, e.Source, e.EnvVar)
+	default:
+		fmt.Fprintf(&b, "%s: not set", e.Key)
+	}
+	for _, c := range e.Candidates {
+		fmt.Fprintf(&b, "\n  checked %s %s", c.Kind, c.Name)
+		if c.Set {
+			b.WriteString(" (set)")
+		}
+	}
+	return b.String()
+}
+
+// Explain calls [Adder.Explain] on the default instance.
+func Explain(key string) Explanation { return defaultAdder.Explain(key) }
+
+// Explain reports where the value of key (e.g. "server.port") came from: the
+// file position, the environment variable, or the [Adder.BindEnv] binding.
+// Keys resolved by [Adder.Unmarshal] are explained as of the most recent call;
+// other keys are looked up in the loaded values and the current environment.
+func (a *Adder) Explain(key string) Explanation {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	a.recordMu.Lock()
+	e, ok := a.provenance[strings.ToLower(key)]
+	a.recordMu.Unlock()
+	if ok {
+		return e
+	}
+
+	if env, ok, err := a.getEnvValue(key); err == nil && ok {
+		return a.explainKey(key, &env, false)
+	}
+	_, inFile := lookupPath(a.configValues, key)
+	return a.explainKey(key, nil, inFile)
+}
+
+// recordSource notes that Unmarshal set key from env, or from the loaded
+// values when env is nil.
+func (a *Adder) recordSource(key string, env *envValue) {
+	e := a.explainKey(key, env, env == nil)
+
+	a.recordMu.Lock()
+	defer a.recordMu.Unlock()
+	if a.provenance == nil {
+		a.provenance = make(map[string]Explanation)
+	}
+	a.provenance[strings.ToLower(key)] = e
+}
+
+func (a *Adder) explainKey(key string, env *envValue, fromFile bool) Explanation {
+	e := Explanation{Key: key}
+
+	names, binding := a.envCandidates(key)
+	kind := SourceEnv
+	if binding {
+		kind = SourceBinding
+	}
+	for _, name := range names {
+		_, set := a.lookupEnv(name)
+		e.Candidates = append(e.Candidates, Candidate{Kind: kind, Name: name, Set: set})
+		if a.fileEnvSuffix {
+			fileVar := name + "_FILE"
+			e.Candidates = append(e.Candidates, Candidate{Kind: kind, Name: fileVar, Set: os.Getenv(fileVar) != ""})
+		}
+	}
+
+	pos, ok := a.origins[strings.ToLower(key)]
+	if ok {
+		e.Candidates = append(e.Candidates, Candidate{Kind: SourceFile, Name: pos.String(), Set: true})
+	}
+
+	switch {
+	case env != nil:
+		e.Source = SourceEnv
+		if env.binding {
+			e.Source = SourceBinding
+		}
+		e.EnvVar = env.name
+	case fromFile:
+		e.Source = SourceFile
+		e.Position = pos
+	}
+	return e
+}
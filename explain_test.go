@@ -0,0 +1,106 @@
+package adder
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainFile(t *testing.T) {
+	a := newTestAdder(t, "log:\n  level: info\nhttp:\n  port: 8080\n")
+
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+
+	e := a.Explain("http.port")
+	assert.Equal(t, SourceFile, e.Source)
+	assert.Equal(t, "application.yaml", filepath.Base(e.Position.File))
+	assert.Equal(t, 4, e.Position.Line)
+	assert.Equal(t, 9, e.Position.Column)
+	assert.True(t, strings.HasSuffix(e.String(), "application.yaml:4:9 (set)"), e.String())
+}
+
+func TestExplainEnv(t *testing.T) {
+	a := newTestAdder(t, "http:\n  port: 8080\nlog:\n  level: info\n")
+	a.SetEnvPrefix("myapp")
+	a.AllowUnprefixedEnv(true)
+	a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	a.AutomaticEnv()
+	t.Setenv("HTTP_PORT", "9090")
+
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	require.Equal(t, uint(9090), cfg.Http.Port)
+
+	e := a.Explain("HTTP.PORT")
+	assert.Equal(t, SourceEnv, e.Source)
+	assert.Equal(t, "HTTP_PORT", e.EnvVar)
+	require.Len(t, e.Candidates, 3)
+	assert.Equal(t, Candidate{Kind: SourceEnv, Name: "MYAPP_HTTP_PORT"}, e.Candidates[0])
+	assert.Equal(t, Candidate{Kind: SourceEnv, Name: "HTTP_PORT", Set: true}, e.Candidates[1])
+	assert.Equal(t, SourceFile, e.Candidates[2].Kind)
+	assert.True(t, e.Candidates[2].Set)
+}
+
+func TestExplainBinding(t *testing.T) {
+	a := newTestAdder(t, "db:\n  url: postgres://localhost\n")
+	require.NoError(t, a.BindEnv("db.url", "DATABASE_URL"))
+	a.EnableFileEnvSuffix()
+	t.Setenv("DATABASE_URL", "postgres://prod")
+
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+
+	e := a.Explain("db.url")
+	assert.Equal(t, SourceBinding, e.Source)
+	assert.Equal(t, "DATABASE_URL", e.EnvVar)
+	assert.Equal(t, []string{"DATABASE_URL", "DATABASE_URL_FILE"}, []string{e.Candidates[0].Name, e.Candidates[1].Name})
+	assert.Equal(t, "db.url: binding DATABASE_URL", strings.SplitN(e.String(), "\n", 2)[0])
+}
+
+func TestExplainWithoutUnmarshal(t *testing.T) {
+	a := newTestAdder(t, "servers:\n  - host: a\n  - host: b\n")
+
+	e := a.Explain("servers.1.host")
+	assert.Equal(t, SourceFile, e.Source)
+	assert.Equal(t, 3, e.Position.Line)
+
+	e = a.Explain("servers.2.host")
+	assert.Equal(t, SourceNone, e.Source)
+	assert.Empty(t, e.Candidates)
+	assert.Equal(t, "servers.2.host: not set", e.String())
+}
+
+func TestExplainIncludedFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"application.yaml": "db: !include db.yaml\nbase: &base\n  level: info\nlog:\n  <<: *base\n",
+		"db.yaml":          "# database\nurl: postgres://localhost\n",
+	})
+
+	a := New()
+	a.SetConfigFile(filepath.Join(dir, "application.yaml"))
+	require.NoError(t, a.ReadInConfig())
+
+	e := a.Explain("db.url")
+	assert.Equal(t, Position{File: filepath.Join(dir, "db.yaml"), Line: 2, Column: 6}, e.Position)
+
+	e = a.Explain("log.level")
+	assert.Equal(t, Position{File: filepath.Join(dir, "application.yaml"), Line: 3, Column: 10}, e.Position)
+}
+
+func TestExplainKeyPerFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"db.url": "postgres://secret\n"})
+
+	a := New()
+	a.AddKeyPerFileDir(dir)
+	require.NoError(t, a.ReadInConfig())
+
+	e := a.Explain("db.url")
+	assert.Equal(t, SourceFile, e.Source)
+	assert.Equal(t, filepath.Join(dir, "db.url"), e.Position.String())
+}
@@ -0,0 +1,403 @@
+package adder
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// envBraceRe matches ${...} references, including the $${...} escape form.
+var envBraceRe = regexp.MustCompile(`\$?\$\{([^}]+)\}`)
+
+// keyRefRe matches dotted names such as "server.host", "svc.billing-api" or
+// "servers.0.port", and ".name" for a top-level key. See isKeyRef.
+var keyRefRe = regexp.MustCompile(`^(\.[\w-]+(\.[\w-]+)*|[\w-]+(\.[\w-]+)+)$`)
+
+// isKeyRef reports whether ref names another config key rather than an
+// environment variable. Any dotted name is a key reference, except that
+// ${VAR-default.value} keeps its meaning as a default: when the text before
+// the first "-" has no dot, the reference is read as a variable with a
+// modifier. Keys whose first segment contains "-" can be referenced with a
+// leading dot, as in ${.billing-api.url}.
+func isKeyRef(ref string) bool {
+	if !keyRefRe.MatchString(ref) {
+		return false
+	}
+	if strings.HasPrefix(ref, ".") {
+		return true
+	}
+	name, _, hasModifier := strings.Cut(ref, "-")
+	return !hasModifier || strings.Contains(name, ".")
+}
+
+// expandEnvBraceOnly replaces ${VAR} references with environment values and
+// ${scheme:arg} references with the result of the registered [Resolver].
+// Shell-style modifiers are supported for environment values:
+//
+//	${VAR:-default}  default when VAR is unset or empty
+//	${VAR-default}   default when VAR is unset
+//	${VAR:?message}  error when VAR is unset or empty
+//	${VAR?message}   error when VAR is unset
+//	${VAR:+alt}      alt when VAR is set and non-empty, otherwise empty
+//	${VAR+alt}       alt when VAR is set, otherwise empty
+//
+// It is used on the raw file contents by [Adder.SetRawInterpolation]. Escapes
+// ($${...}) and config key references are left in place for the parser, and
+// substituted values are escaped so that they stay literal. A substituted
+// value ending in "$" directly before another reference is the one case this
+// cannot represent; it is read as an escape.
+func (a *Adder) expandEnvBraceOnly(s string) (string, error) {
+	var b strings.Builder
+	last := 0
+	for _, loc := range envBraceRe.FindAllStringIndex(s, -1) {
+		match := s[loc[0]:loc[1]]
+		if strings.HasPrefix(match, "$$") || isKeyRef(match[2:len(match)-1]) {
+			continue
+		}
+		v, err := a.expandRef(match[2 : len(match)-1])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(s[last:loc[0]])
+		b.WriteString(escapeRefs(v, strings.HasPrefix(s[loc[1]:], "{")))
+		last = loc[1]
+	}
+	b.WriteString(s[last:])
+	return b.String(), nil
+}
+
+// escapeRefs turns every ${ in s into $${, so that text from outside the config
+// file, such as an environment variable or a secret, is read as literal text
+// when spliced into YAML source. beforeBrace reports whether s will be
+// followed by "{", in which case a trailing $ is escaped too.
+func escapeRefs(s string, beforeBrace bool) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	if beforeBrace && strings.HasSuffix(s, "$") {
+		s += "$"
+	}
+	return s
+}
+
+// template is a config string that refers to other config keys. The references
+// are resolved by [Adder.Unmarshal], so that they see env overrides and every
+// layer; all other text, including substituted env values, is literal.
+type template []templatePart
+
+// templatePart is either literal text or a key reference as written, without
+// the braces (e.g. "server.host" or ".name").
+type templatePart struct {
+	text string
+	ref  string
+}
+
+// String returns the template as it would be written in a config file, which
+// is what readers of the loaded values, such as change diffs, see.
+func (t template) String() string {
+	var b strings.Builder
+	for _, part := range t {
+		if part.ref != "" {
+			b.WriteString("${" + part.ref + "}")
+		} else {
+			b.WriteString(part.text)
+		}
+	}
+	return b.String()
+}
+
+// interpolateValues expands references in every string value of a parsed
+// config, in place. Map keys are left untouched. Strings that refer to other
+// keys are replaced by a [template]. With expandEnv unset, only escapes and key
+// references are handled, as the env references were already expanded in the
+// raw file contents.
+func (a *Adder) interpolateValues(m map[string]any, prefix string, expandEnv bool) error {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		expanded, err := a.interpolateValue(v, key, expandEnv)
+		if err != nil {
+			return err
+		}
+		m[k] = expanded
+	}
+	return nil
+}
+
+func (a *Adder) interpolateValue(v any, keyPath string, expandEnv bool) (any, error) {
+	switch v := v.(type) {
+	case string:
+		parsed, err := a.parseString(v, expandEnv)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", keyPath, err)
+		}
+		return parsed, nil
+	case map[string]any:
+		return v, a.interpolateValues(v, keyPath, expandEnv)
+	case []any:
+		for i, item := range v {
+			expanded, err := a.interpolateValue(item, keyPath+"."+strconv.Itoa(i), expandEnv)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+	}
+	return v, nil
+}
+
+// parseString expands the env and resolver references in s and turns $${...}
+// escapes into literal ${...}. It returns a [template] if s refers to other
+// config keys, and the expanded string otherwise.
+func (a *Adder) parseString(s string, expandEnv bool) (any, error) {
+	var t template
+	var text strings.Builder
+	last := 0
+	for _, loc := range envBraceRe.FindAllStringIndex(s, -1) {
+		match := s[loc[0]:loc[1]]
+		ref := match[2 : len(match)-1]
+		text.WriteString(s[last:loc[0]])
+		last = loc[1]
+
+		switch {
+		case strings.HasPrefix(match, "$$"):
+			text.WriteString(match[1:])
+		case isKeyRef(ref):
+			if text.Len() > 0 {
+				t = append(t, templatePart{text: text.String()})
+				text.Reset()
+			}
+			t = append(t, templatePart{ref: ref})
+		case expandEnv:
+			v, err := a.expandRef(ref)
+			if err != nil {
+				return nil, err
+			}
+			text.WriteString(v)
+		default:
+			text.WriteString(match)
+		}
+	}
+	text.WriteString(s[last:])
+
+	if t == nil {
+		return text.String(), nil
+	}
+	if text.Len() > 0 {
+		t = append(t, templatePart{text: text.String()})
+	}
+	return t, nil
+}
+
+// extractTemplates replaces every [template] in v with its string form and
+// records it in out under its lower-cased key path.
+func extractTemplates(prefix string, v any, out map[string]template) {
+	join := func(k string) string {
+		if prefix == "" {
+			return strings.ToLower(k)
+		}
+		return prefix + "." + strings.ToLower(k)
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			if t, ok := item.(template); ok {
+				out[join(k)] = t
+				v[k] = t.String()
+				continue
+			}
+			extractTemplates(join(k), item, out)
+		}
+	case []any:
+		for i, item := range v {
+			key := join(strconv.Itoa(i))
+			if t, ok := item.(template); ok {
+				out[key] = t
+				v[i] = t.String()
+				continue
+			}
+			extractTemplates(key, item, out)
+		}
+	}
+}
+
+// resolveString returns the effective text of the string value s found at
+// keyPath, resolving the key references recorded when the config was loaded.
+// Strings without references are returned as they are. chain holds the keys
+// being resolved and is used to detect reference cycles. If a referenced key
+// was read from a secret file, its env value is returned too, so that callers
+// can keep the text out of error messages.
+func (a *Adder) resolveString(keyPath, s string, chain []string) (string, *envValue, error) {
+	t, ok := a.templates[strings.ToLower(keyPath)]
+	if !ok {
+		return s, nil, nil
+	}
+
+	var b strings.Builder
+	var secret *envValue
+	for _, part := range t {
+		if part.ref == "" {
+			b.WriteString(part.text)
+			continue
+		}
+		v, src, err := a.resolveKeyRef(strings.TrimPrefix(part.ref, "."), chain)
+		if err != nil {
+			return "", nil, err
+		}
+		if secret == nil {
+			secret = src
+		}
+		b.WriteString(v)
+	}
+	return b.String(), secret, nil
+}
+
+func (a *Adder) resolveKeyRef(key string, chain []string) (string, *envValue, error) {
+	for _, k := range chain {
+		if strings.EqualFold(k, key) {
+			return "", nil, fmt.Errorf("reference cycle: %s -> %s", strings.Join(chain, " -> "), key)
+		}
+	}
+
+	envVal, ok, err := a.getEnvValue(key)
+	if err != nil {
+		return "", nil, err
+	}
+	if ok {
+		if envVal.file != "" {
+			return envVal.value, &envVal, nil
+		}
+		return envVal.value, nil, nil
+	}
+
+	v, ok := lookupPath(a.configValues, key)
+	if !ok {
+		return "", nil, fmt.Errorf("undefined key %q", key)
+	}
+	switch v := v.(type) {
+	case nil:
+		return "", nil, nil
+	case string:
+		return a.resolveString(key, v, append(chain[:len(chain):len(chain)], key))
+	case map[string]any, []any:
+		return "", nil, fmt.Errorf("key %q is not a scalar value", key)
+	default:
+		return fmt.Sprintf("%v", v), nil, nil
+	}
+}
+
+// lookupPath finds a dotted key in parsed config values. Map keys are matched
+// case-insensitively and numeric segments index into lists.
+func lookupPath(m map[string]any, key string) (any, bool) {
+	var cur any = m
+	for _, part := range strings.Split(key, ".") {
+		switch node := cur.(type) {
+		case map[string]any:
+			v, ok := caseInsensitiveLookup(node, part)
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			cur = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// expandRef expands a single reference, dispatching ${scheme:arg} to a
+// registered [Resolver] and everything else to the environment.
+func (a *Adder) expandRef(ref string) (string, error) {
+	if r, scheme, arg, ok := a.lookupResolver(ref); ok {
+		v, err := r.Resolve(arg)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", scheme, err)
+		}
+		return v, nil
+	}
+	return a.expandEnvRef(ref)
+}
+
+func (a *Adder) expandEnvRef(ref string) (string, error) {
+	name, op, arg := splitModifier(ref)
+	if strings.Contains(name, ".") {
+		return "", fmt.Errorf("invalid variable name %q", name)
+	}
+	value, set := a.lookupExpandEnv(name)
+
+	switch op {
+	case "":
+		return value, nil
+	case ":-":
+		if value == "" {
+			return arg, nil
+		}
+	case "-":
+		if !set {
+			return arg, nil
+		}
+	case ":?":
+		if value == "" {
+			return "", requiredVarError(name, arg)
+		}
+	case "?":
+		if !set {
+			return "", requiredVarError(name, arg)
+		}
+	case ":+":
+		if value != "" {
+			return arg, nil
+		}
+		return "", nil
+	case "+":
+		if set {
+			return arg, nil
+		}
+		return "", nil
+	}
+	return value, nil
+}
+
+// splitModifier splits "NAME:-arg" into its name, operator and argument.
+func splitModifier(ref string) (name, op, arg string) {
+	i := strings.IndexAny(ref, ":-?+")
+	if i < 0 {
+		return ref, "", ""
+	}
+	name, rest := ref[:i], ref[i:]
+	for _, candidate := range []string{":-", ":?", ":+", "-", "?", "+"} {
+		if arg, ok := strings.CutPrefix(rest, candidate); ok {
+			return name, candidate, arg
+		}
+	}
+	return ref, "", ""
+}
+
+func requiredVarError(name, msg string) error {
+	if msg == "" {
+		msg = "required variable is not set"
+	}
+	return fmt.Errorf("%s: %s", name, msg)
+}
+
+func (a *Adder) lookupExpandEnv(name string) (string, bool) {
+	names := []string{name}
+	if a.expandWithPrefix {
+		names = a.envNames(name)
+	}
+	for _, n := range names {
+		if v, ok := os.LookupEnv(n); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,381 @@
+package adder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnvModifiers(t *testing.T) {
+	t.Setenv("SET_VAR", "value")
+	t.Setenv("EMPTY_VAR", "")
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"${SET_VAR:-fallback}", "value"},
+		{"${EMPTY_VAR:-fallback}", "fallback"},
+		{"${UNSET_VAR_12345:-fallback}", "fallback"},
+		{"${SET_VAR-fallback}", "value"},
+		{"${EMPTY_VAR-fallback}", ""},
+		{"${UNSET_VAR_12345-fallback}", "fallback"},
+		{"${SET_VAR:+alt}", "alt"},
+		{"${EMPTY_VAR:+alt}", ""},
+		{"${UNSET_VAR_12345:+alt}", ""},
+		{"${EMPTY_VAR+alt}", "alt"},
+		{"${UNSET_VAR_12345+alt}", ""},
+		{"${SET_VAR:?must be set}", "value"},
+		{"${EMPTY_VAR?must be set}", ""},
+		{"http://${UNSET_VAR_12345:-localhost}:${UNSET_PORT_12345:-8080}/", "http://localhost:8080/"},
+		{"${UNSET_VAR_12345:-a:b-c}", "a:b-c"},
+	}
+
+	a := New()
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := a.expandEnvBraceOnly(tc.in)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestExpandEnvRequiredVar(t *testing.T) {
+	t.Setenv("EMPTY_VAR", "")
+	a := New()
+
+	_, err := a.expandEnvBraceOnly("${UNSET_VAR_12345:?database password must be provided}")
+	require.Error(t, err)
+	assert.EqualError(t, err, "UNSET_VAR_12345: database password must be provided")
+
+	_, err = a.expandEnvBraceOnly("${EMPTY_VAR:?}")
+	require.Error(t, err)
+	assert.EqualError(t, err, "EMPTY_VAR: required variable is not set")
+}
+
+func TestReadInConfigRequiredVarFails(t *testing.T) {
+	dir := t.TempDir()
+	content := "db:\n  url: postgres://${DB_HOST:?DB_HOST is required}/mydb\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "application.yaml"), []byte(content), 0o644))
+
+	a := New()
+	a.SetConfigName("application")
+	a.SetConfigType("yaml")
+	a.AddConfigPath(dir)
+
+	err := a.ReadInConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DB_HOST: DB_HOST is required")
+
+	t.Setenv("DB_HOST", "prod-db")
+	require.NoError(t, a.ReadInConfig())
+
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "postgres://prod-db/mydb", cfg.Db.URL)
+}
+
+func TestExpandEnvEscape(t *testing.T) {
+	type config struct {
+		Summary string
+		Alert   string
+		Command string
+	}
+
+	t.Setenv("SET_VAR", "value")
+	a := newTestAdder(t, `
+summary: literal $${SET_VAR}, expanded ${SET_VAR}
+alert: "{{ $${labels.instance} }}"
+command: echo $${UNSET_VAR_12345:?never evaluated}
+`)
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "literal ${SET_VAR}, expanded value", cfg.Summary)
+	assert.Equal(t, "{{ ${labels.instance} }}", cfg.Alert)
+	assert.Equal(t, "echo ${UNSET_VAR_12345:?never evaluated}", cfg.Command)
+}
+
+func TestSetInterpolation(t *testing.T) {
+	type config struct {
+		Alert   string
+		Command string
+	}
+
+	dir := t.TempDir()
+	content := "alert: \"{{ $${labels.instance} }}\"\ncommand: echo ${HOME_DIR_12345:?unset}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "application.yaml"), []byte(content), 0o644))
+
+	a := New()
+	a.SetConfigName("application")
+	a.SetConfigType("yaml")
+	a.AddConfigPath(dir)
+	a.SetInterpolation(false)
+	require.NoError(t, a.ReadInConfig())
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "{{ $${labels.instance} }}", cfg.Alert)
+	assert.Equal(t, "echo ${HOME_DIR_12345:?unset}", cfg.Command)
+
+	a.SetInterpolation(true)
+	err := a.ReadInConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HOME_DIR_12345: unset")
+}
+
+func TestInterpolationAfterParsing(t *testing.T) {
+	type config struct {
+		Name  string
+		Admin bool
+		Http  testHTTPConfig
+		Tags  []string
+	}
+
+	content := `# owner: ${OWNER:?comments are not expanded}
+name: ${APP_NAME}
+http:
+  port: ${APP_PORT}
+tags:
+  - ${APP_TAG:-default}
+`
+
+	t.Run("substituted values cannot inject keys", func(t *testing.T) {
+		t.Setenv("APP_NAME", "svc\nadmin: true")
+		t.Setenv("APP_PORT", "9090")
+		a := newTestAdder(t, content)
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "svc\nadmin: true", cfg.Name)
+		assert.False(t, cfg.Admin)
+		assert.Equal(t, uint(9090), cfg.Http.Port)
+		assert.Equal(t, []string{"default"}, cfg.Tags)
+	})
+
+	t.Run("values stay strings", func(t *testing.T) {
+		t.Setenv("APP_NAME", "svc: {x: 1}")
+		t.Setenv("APP_PORT", "9090")
+		a := newTestAdder(t, content)
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "svc: {x: 1}", cfg.Name)
+	})
+
+	t.Run("error names the key", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "application.yaml"), []byte("db:\n  url: ${DB_URL_12345:?missing}\n"), 0o644))
+
+		a := New()
+		a.SetConfigFile(filepath.Join(dir, "application.yaml"))
+		err := a.ReadInConfig()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "db.url: DB_URL_12345: missing")
+	})
+}
+
+func TestSetRawInterpolation(t *testing.T) {
+	type config struct {
+		Name  string
+		Admin bool
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "application.yaml"), []byte("name: ${APP_NAME}\n"), 0o644))
+	t.Setenv("APP_NAME", "svc\nadmin: true")
+
+	a := New()
+	a.SetConfigName("application")
+	a.SetConfigType("yaml")
+	a.AddConfigPath(dir)
+	a.SetRawInterpolation(true)
+	require.NoError(t, a.ReadInConfig())
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "svc", cfg.Name)
+	assert.True(t, cfg.Admin)
+}
+
+func TestCrossKeyReferences(t *testing.T) {
+	type server struct {
+		Host string
+		Port int
+	}
+	type config struct {
+		Name     string
+		Server   server
+		URL      string `mapstructure:"url"`
+		Mirrors  []string
+		Headers  map[string]string
+		Template string
+	}
+
+	content := `
+name: billing
+server:
+  host: localhost
+  port: 8080
+url: "http://${server.host}:${server.port}/api"
+mirrors:
+  - "${.url}/v1"
+  - "https://${.name}.example.com"
+headers:
+  X-Service: "${.name}"
+template: "$${server.host}"
+`
+
+	t.Run("resolves keys", func(t *testing.T) {
+		a := newTestAdder(t, content)
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "http://localhost:8080/api", cfg.URL)
+		assert.Equal(t, []string{"http://localhost:8080/api/v1", "https://billing.example.com"}, cfg.Mirrors)
+		assert.Equal(t, map[string]string{"X-Service": "billing"}, cfg.Headers)
+		assert.Equal(t, "${server.host}", cfg.Template)
+	})
+
+	t.Run("sees env overrides", func(t *testing.T) {
+		a := newTestAdder(t, content)
+		a.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		a.AutomaticEnv()
+		t.Setenv("SERVER_HOST", "api.internal")
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "api.internal", cfg.Server.Host)
+		assert.Equal(t, "http://api.internal:8080/api", cfg.URL)
+	})
+
+	t.Run("undefined key", func(t *testing.T) {
+		a := newTestAdder(t, "url: \"${server.missing}\"\n")
+
+		var cfg config
+		err := a.Unmarshal(&cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid reference at url: undefined key "server.missing"`)
+	})
+
+	t.Run("hyphenated keys", func(t *testing.T) {
+		a := newTestAdder(t, `
+svc:
+  billing-api: http://b
+billing-api:
+  url: http://c
+url: ${svc.billing-api}/x
+mirrors:
+  - ${.billing-api.url}
+  - ${UNSET_VAR_12345-localhost.localdomain}
+`)
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "http://b/x", cfg.URL)
+		assert.Equal(t, []string{"http://c", "localhost.localdomain"}, cfg.Mirrors)
+	})
+
+	t.Run("dotted variable name", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "application.yaml"), []byte("url: ${server.host:-x}\n"), 0o644))
+		a := New()
+		a.SetConfigFile(filepath.Join(dir, "application.yaml"))
+
+		err := a.ReadInConfig()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid variable name "server.host"`)
+	})
+
+	t.Run("cycle reports chain", func(t *testing.T) {
+		a := newTestAdder(t, `
+url: "${server.host}"
+server:
+  host: "${a.b}"
+a:
+  b: "${.url}"
+`)
+
+		var cfg config
+		err := a.Unmarshal(&cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "reference cycle: server.host -> a.b -> url -> server.host")
+	})
+}
+
+func TestSubstitutedValuesAreLiteral(t *testing.T) {
+	type db struct {
+		User     string
+		Password string
+		Secret   string
+		Label    string
+		Prefixed string
+	}
+	type config struct {
+		Db  db
+		URL string `mapstructure:"url"`
+	}
+
+	secret := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secret, []byte("s$${x}${db.user}\n"), 0o600))
+	t.Setenv("DOLLAR", "$")
+
+	content := `
+db:
+  user: admin
+  password: ${DBPW}
+  secret: ${file:` + secret + `}
+  label: ${DOLLAR}{db.user}
+  prefixed: ${DOLLAR}${db.user}
+url: ${db.password}
+`
+
+	for _, tc := range []struct{ dbpw, want string }{
+		{"p$${x}w", "p$${x}w"},
+		{"${db.user}", "${db.user}"},
+		{"${UNSET_VAR_12345:-x}", "${UNSET_VAR_12345:-x}"},
+	} {
+		t.Run(tc.dbpw, func(t *testing.T) {
+			t.Setenv("DBPW", tc.dbpw)
+			a := newTestAdder(t, content)
+
+			var cfg config
+			require.NoError(t, a.Unmarshal(&cfg))
+			assert.Equal(t, "admin", cfg.Db.User)
+			assert.Equal(t, tc.want, cfg.Db.Password)
+			assert.Equal(t, tc.want, cfg.URL)
+			assert.Equal(t, "${db.user}", cfg.Db.Label)
+			assert.Equal(t, "$admin", cfg.Db.Prefixed)
+			assert.Equal(t, "s$${x}${db.user}", cfg.Db.Secret)
+		})
+	}
+
+	t.Run("raw interpolation", func(t *testing.T) {
+		t.Setenv("DBPW", "p$${x}w")
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "application.yaml"), []byte("db:\n  password: ${DBPW}\n"), 0o644))
+		a := New()
+		a.SetConfigFile(filepath.Join(dir, "application.yaml"))
+		a.SetRawInterpolation(true)
+		require.NoError(t, a.ReadInConfig())
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "p$${x}w", cfg.Db.Password)
+	})
+
+	t.Run("key reference to env override", func(t *testing.T) {
+		a := newTestAdder(t, "db:\n  password: plain\nurl: ${db.password}\n")
+		require.NoError(t, a.BindEnv("db.password", "DBPW"))
+		t.Setenv("DBPW", "${db.user}")
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "${db.user}", cfg.URL)
+	})
+}
@@ -0,0 +1,104 @@
+package adder
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Live holds a typed configuration snapshot that is rebuilt on every reload.
+// Readers call [Live.Load] to get a consistent, immutable snapshot without
+// taking locks. Create one with [NewLive].
+type Live[T any] struct {
+	a        *Adder
+	validate func(*T) error
+	current  atomic.Pointer[T]
+
+	mu      sync.Mutex
+	err     error
+	onError []func(error)
+}
+
+// NewLive unmarshals the current configuration of a into a new T, validates it
+// and returns a Live that republishes a fresh T after every reload reported to
+// [Adder.OnConfigChange]. A nil a uses the default instance.
+//
+// validate may be nil. If *T has a Validate() error method it is called as
+// well. When a reload, [Adder.Unmarshal] or validation fails, the last good
+// snapshot is kept and the error is reported through [Live.Err] and
+// [Live.OnError]. NewLive itself returns an error if the initial snapshot
+// cannot be built.
+func NewLive[T any](a *Adder, validate func(*T) error) (*Live[T], error) {
+	if a == nil {
+		a = defaultAdder
+	}
+	l := &Live[T]{a: a, validate: validate}
+
+	v, err := l.build()
+	if err != nil {
+		return nil, err
+	}
+	l.current.Store(v)
+	a.OnConfigChange(l.update)
+	return l, nil
+}
+
+// Load returns the current snapshot. Callers must not modify it.
+func (l *Live[T]) Load() *T {
+	return l.current.Load()
+}
+
+// Err returns the error from the most recent reload, or nil if it succeeded.
+func (l *Live[T]) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// OnError registers a callback for reloads that were rejected, leaving the
+// previous snapshot in place.
+func (l *Live[T]) OnError(fn func(error)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onError = append(l.onError, fn)
+}
+
+func (l *Live[T]) update(e Event) {
+	err := e.Err
+	if err == nil {
+		var v *T
+		if v, err = l.build(); err == nil {
+			l.current.Store(v)
+		}
+	}
+
+	l.mu.Lock()
+	l.err = err
+	callbacks := slices.Clone(l.onError)
+	l.mu.Unlock()
+
+	if err != nil {
+		for _, fn := range callbacks {
+			fn(err)
+		}
+	}
+}
+
+func (l *Live[T]) build() (*T, error) {
+	v := new(T)
+	if err := l.a.Unmarshal(v); err != nil {
+		return nil, err
+	}
+	if l.validate != nil {
+		if err := l.validate(v); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+	if validator, ok := any(v).(interface{ Validate() error }); ok {
+		if err := validator.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+	return v, nil
+}
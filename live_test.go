@@ -0,0 +1,121 @@
+package adder
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type liveConfig struct {
+	Log  testLogConfig
+	Http testHTTPConfig
+}
+
+func (c *liveConfig) Validate() error {
+	if c.Http.Port == 0 {
+		return errors.New("http.port is required")
+	}
+	return nil
+}
+
+func TestLive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "application.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: info\nhttp:\n  port: 8080\n"), 0o644))
+
+	a := New()
+	a.SetConfigFile(path)
+	require.NoError(t, a.ReadInConfig())
+
+	live, err := NewLive[liveConfig](a, func(c *liveConfig) error {
+		if c.Log.Level == "" {
+			return errors.New("log.level is required")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	errs := make(chan error, 10)
+	live.OnError(func(err error) { errs <- err })
+
+	first := live.Load()
+	assert.Equal(t, "info", first.Log.Level)
+	assert.Equal(t, uint(8080), first.Http.Port)
+
+	reload := func(content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		a.reload(Event{Path: path})
+	}
+
+	t.Run("publishes new snapshot", func(t *testing.T) {
+		reload("log:\n  level: debug\nhttp:\n  port: 9090\n")
+		require.NoError(t, live.Err())
+		assert.Equal(t, "debug", live.Load().Log.Level)
+		assert.Equal(t, uint(9090), live.Load().Http.Port)
+		assert.Equal(t, "info", first.Log.Level, "old snapshots are not modified")
+	})
+
+	t.Run("validation failure keeps last good value", func(t *testing.T) {
+		reload("http:\n  port: 7070\n")
+		require.Error(t, live.Err())
+		assert.Contains(t, live.Err().Error(), "invalid config: log.level is required")
+		assert.Equal(t, uint(9090), live.Load().Http.Port)
+		assert.Contains(t, (<-errs).Error(), "log.level is required")
+	})
+
+	t.Run("Validate method is called", func(t *testing.T) {
+		reload("log:\n  level: warn\n")
+		require.Error(t, live.Err())
+		assert.Contains(t, live.Err().Error(), "http.port is required")
+		assert.Equal(t, "debug", live.Load().Log.Level)
+		<-errs
+	})
+
+	t.Run("unmarshal failure keeps last good value", func(t *testing.T) {
+		reload("log:\n  level: warn\nhttp:\n  port: not-a-port\n")
+		require.Error(t, live.Err())
+		assert.Equal(t, "debug", live.Load().Log.Level)
+		<-errs
+	})
+
+	t.Run("reload failure keeps last good value", func(t *testing.T) {
+		reload("log: [\n")
+		require.Error(t, live.Err())
+		assert.Contains(t, live.Err().Error(), "failed to parse yaml")
+		assert.Equal(t, "debug", live.Load().Log.Level)
+		<-errs
+	})
+
+	t.Run("recovers", func(t *testing.T) {
+		reload("log:\n  level: error\nhttp:\n  port: 80\n")
+		require.NoError(t, live.Err())
+		assert.Equal(t, "error", live.Load().Log.Level)
+	})
+}
+
+func TestLiveWithWatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "application.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: info\nhttp:\n  port: 8080\n"), 0o644))
+
+	a, events := newWatchedAdder(t, path)
+	live, err := NewLive[liveConfig](a, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: debug\nhttp:\n  port: 8080\n"), 0o644))
+	require.NoError(t, waitEvent(t, events).Err)
+
+	// The Live callback was registered after the test's, so poll briefly.
+	require.Eventually(t, func() bool { return live.Load().Log.Level == "debug" }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestNewLiveInitialError(t *testing.T) {
+	a := newTestAdder(t, "log:\n  level: info\n")
+	_, err := NewLive[liveConfig](a, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "http.port is required")
+}
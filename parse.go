@@ -0,0 +1,96 @@
+package adder
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// unitSuffixes lists the multipliers accepted after an integer, following the
+// Kubernetes quantity convention: binary (Ki, Mi, ...) and decimal (k, M, ...).
+var unitSuffixes = []struct {
+	suffix string
+	mult   uint64
+}{
+	{"Ki", 1 << 10},
+	{"Mi", 1 << 20},
+	{"Gi", 1 << 30},
+	{"Ti", 1 << 40},
+	{"Pi", 1 << 50},
+	{"Ei", 1 << 60},
+	{"k", 1e3},
+	{"M", 1e6},
+	{"G", 1e9},
+	{"T", 1e12},
+	{"P", 1e15},
+	{"E", 1e18},
+}
+
+// parseUint parses s as an unsigned integer that fits in bitSize bits. Base
+// prefixes (0x, 0o, 0b, leading 0), underscores and unit suffixes are accepted.
+func parseUint(s string, bitSize int) (uint64, error) {
+	s = strings.TrimSpace(s)
+	u, err := strconv.ParseUint(s, 0, bitSize)
+	if err == nil {
+		return u, nil
+	}
+
+	for _, unit := range unitSuffixes {
+		num, ok := strings.CutSuffix(s, unit.suffix)
+		if !ok || num == "" {
+			continue
+		}
+		n, nerr := strconv.ParseUint(num, 0, bitSize)
+		if nerr != nil {
+			break
+		}
+		if n > (math.MaxUint64>>(64-bitSize))/unit.mult {
+			return 0, &strconv.NumError{Func: "ParseUint", Num: s, Err: strconv.ErrRange}
+		}
+		return n * unit.mult, nil
+	}
+
+	return 0, err
+}
+
+// parseInt is the signed counterpart of [parseUint].
+func parseInt(s string, bitSize int) (int64, error) {
+	s = strings.TrimSpace(s)
+	body, neg := s, false
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		body, neg = rest, true
+	} else if rest, ok := strings.CutPrefix(s, "+"); ok {
+		body = rest
+	}
+
+	u, err := parseUint(body, 64)
+	if err != nil {
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) {
+			return 0, &strconv.NumError{Func: "ParseInt", Num: s, Err: numErr.Err}
+		}
+		return 0, err
+	}
+
+	limit := uint64(1) << (bitSize - 1)
+	if (neg && u > limit) || (!neg && u >= limit) {
+		return 0, &strconv.NumError{Func: "ParseInt", Num: s, Err: strconv.ErrRange}
+	}
+	if neg {
+		return -int64(u), nil
+	}
+	return int64(u), nil
+}
+
+// parseBool accepts true/false, yes/no, on/off and 1/0, case-insensitively.
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q is not a valid boolean (use true/false, yes/no, on/off or 1/0)", s)
+}
@@ -0,0 +1,95 @@
+package adder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInt(t *testing.T) {
+	tests := []struct {
+		in      string
+		bitSize int
+		want    int64
+	}{
+		{"42", 64, 42},
+		{"-42", 64, -42},
+		{"+7", 64, 7},
+		{"0x1F", 64, 31},
+		{"0o755", 64, 493},
+		{"0755", 64, 493},
+		{"0b101", 64, 5},
+		{"1_000_000", 64, 1000000},
+		{"64k", 64, 64000},
+		{"512Mi", 64, 512 << 20},
+		{"-2Gi", 64, -2 << 30},
+		{"0x1E", 64, 30},
+		{"127", 8, 127},
+		{"-128", 8, -128},
+		{"-9223372036854775808", 64, -9223372036854775808},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := parseInt(tc.in, tc.bitSize)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseIntErrors(t *testing.T) {
+	tests := []struct {
+		in      string
+		bitSize int
+		want    string
+	}{
+		{"not-a-number", 64, "invalid syntax"},
+		{"", 64, "invalid syntax"},
+		{"12x", 64, "invalid syntax"},
+		{"128", 8, "value out of range"},
+		{"-129", 8, "value out of range"},
+		{"1Ki", 8, "value out of range"},
+		{"9223372036854775808", 64, "value out of range"},
+		{"10E", 64, "value out of range"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			_, err := parseInt(tc.in, tc.bitSize)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestParseUint(t *testing.T) {
+	got, err := parseUint("0xFF", 8)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(255), got)
+
+	got, err = parseUint("16Ei", 64)
+	require.Error(t, err)
+	assert.Zero(t, got)
+	assert.Contains(t, err.Error(), "value out of range")
+
+	_, err = parseUint("-1", 64)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid syntax")
+}
+
+func TestParseBool(t *testing.T) {
+	for _, in := range []string{"true", "True", "YES", "on", "1", " true "} {
+		b, err := parseBool(in)
+		require.NoError(t, err, in)
+		assert.True(t, b, in)
+	}
+	for _, in := range []string{"false", "FALSE", "no", "Off", "0"} {
+		b, err := parseBool(in)
+		require.NoError(t, err, in)
+		assert.False(t, b, in)
+	}
+	_, err := parseBool("maybe")
+	require.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package adder
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var pathVarRe = regexp.MustCompile(`\$\{(\w+)\}|\$(\w+)`)
+
+// expandPath expands a leading ~ to the user's home directory and replaces
+// $VAR and ${VAR} with environment variables. References to unset variables
+// are left as they are, since "$" may be part of a directory name.
+func expandPath(path string) string {
+	path = pathVarRe.ReplaceAllStringFunc(path, func(ref string) string {
+		m := pathVarRe.FindStringSubmatch(ref)
+		if v, ok := os.LookupEnv(m[1] + m[2]); ok {
+			return v
+		}
+		return ref
+	})
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = home + path[1:]
+		}
+	}
+	return path
+}
+
+// AddXDGConfigPaths calls [Adder.AddXDGConfigPaths] on the default instance.
+func AddXDGConfigPaths(app string) { defaultAdder.AddXDGConfigPaths(app) }
+
+// AddXDGConfigPaths adds the config directories for app defined by the XDG Base
+// Directory spec, most important first: $XDG_CONFIG_HOME/app (default
+// ~/.config/app), then app under each entry of $XDG_CONFIG_DIRS (default
+// /etc/xdg). Relative entries are ignored, as the spec requires. The variables
+// are read when this is called.
+func (a *Adder) AddXDGConfigPaths(app string) {
+	var dirs []string
+	if home := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(home) {
+		dirs = append(dirs, home)
+	} else if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config"))
+	}
+
+	configDirs := os.Getenv("XDG_CONFIG_DIRS")
+	if configDirs == "" {
+		configDirs = "/etc/xdg"
+	}
+	for _, dir := range filepath.SplitList(configDirs) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, dir := range dirs {
+		a.configPaths = append(a.configPaths, filepath.Join(dir, app))
+	}
+}
+
+// AddParentSearch calls [Adder.AddParentSearch] on the default instance.
+func AddParentSearch(dir string) { defaultAdder.AddParentSearch(dir) }
+
+// AddParentSearch adds dir and each of its parents as search paths, nearest
+// first, stopping at the repository root (the first directory containing
+// .git) or the filesystem root. This lets command-line tools find their config
+// when run from a subdirectory, e.g. AddParentSearch("."). Relative paths are
+// resolved against the working directory when this is called.
+func (a *Adder) AddParentSearch(dir string) {
+	dir, err := filepath.Abs(expandPath(dir))
+	if err != nil {
+		return
+	}
+
+	var dirs []string
+	for {
+		dirs = append(dirs, dir)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.configPaths = append(a.configPaths, dirs...)
+}
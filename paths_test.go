@@ -0,0 +1,125 @@
+package adder
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandPath(t *testing.T) {
+	t.Setenv("HOME", "/home/test")
+	t.Setenv("APP_DIR", "/opt/app")
+
+	tests := map[string]string{
+		"~":                       "/home/test",
+		"~/.myapp":                "/home/test/.myapp",
+		"$HOME/.myapp":            "/home/test/.myapp",
+		"${APP_DIR}/config":       "/opt/app/config",
+		"~other/config":           "~other/config",
+		"/etc/myapp":              "/etc/myapp",
+		"/srv/${UNSET_VAR_12345}": "/srv/${UNSET_VAR_12345}",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, expandPath(in), in)
+	}
+}
+
+func TestAddConfigPathExpansion(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeFiles(t, home, map[string]string{".myapp/application.yaml": "log:\n  level: debug\n"})
+
+	for _, path := range []string{"~/.myapp", "${HOME}/.myapp"} {
+		a := New()
+		a.SetConfigName("application")
+		a.SetConfigType("yaml")
+		a.AddConfigPath(path)
+		require.NoError(t, a.ReadInConfig(), path)
+
+		var cfg testConfig
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "debug", cfg.Log.Level)
+	}
+}
+
+func TestAddXDGConfigPaths(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("HOME", "/home/test")
+		t.Setenv("XDG_CONFIG_HOME", "")
+		t.Setenv("XDG_CONFIG_DIRS", "")
+
+		a := New()
+		a.AddXDGConfigPaths("myapp")
+		assert.Equal(t, []string{"/home/test/.config/myapp", "/etc/xdg/myapp"}, a.configPaths)
+	})
+
+	t.Run("from environment", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "/xdg/home")
+		t.Setenv("XDG_CONFIG_DIRS", "/xdg/a:relative:/xdg/b")
+
+		a := New()
+		a.AddXDGConfigPaths("myapp")
+		assert.Equal(t, []string{"/xdg/home/myapp", "/xdg/a/myapp", "/xdg/b/myapp"}, a.configPaths)
+	})
+
+	t.Run("finds config", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "home"))
+		t.Setenv("XDG_CONFIG_DIRS", filepath.Join(dir, "system"))
+		writeFiles(t, dir, map[string]string{"system/myapp/application.yaml": "log:\n  level: warn\n"})
+
+		a := New()
+		a.SetConfigName("application")
+		a.SetConfigType("yaml")
+		a.AddXDGConfigPaths("myapp")
+		require.NoError(t, a.ReadInConfig())
+
+		var cfg testConfig
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "warn", cfg.Log.Level)
+	})
+}
+
+func TestAddParentSearch(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"repo/application.yaml": "log:\n  level: info\n",
+		"repo/cmd/tool/main.go": "package main\n",
+		"application.yaml":      "log:\n  level: outside\n",
+		"repo/.git/HEAD":        "ref: refs/heads/main\n",
+		"other/cmd/placeholder": "",
+		"other/application.yml": "log:\n  level: other\n",
+	})
+
+	t.Run("stops at repository root", func(t *testing.T) {
+		a := New()
+		a.AddParentSearch(filepath.Join(root, "repo", "cmd", "tool"))
+		assert.Equal(t, []string{
+			filepath.Join(root, "repo", "cmd", "tool"),
+			filepath.Join(root, "repo", "cmd"),
+			filepath.Join(root, "repo"),
+		}, a.configPaths)
+
+		a.SetConfigName("application")
+		a.SetConfigType("yaml")
+		require.NoError(t, a.ReadInConfig())
+		var cfg testConfig
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "info", cfg.Log.Level)
+	})
+
+	t.Run("relative to working directory", func(t *testing.T) {
+		t.Chdir(filepath.Join(root, "other", "cmd"))
+
+		a := New()
+		a.SetConfigName("application")
+		a.SetConfigType("yaml")
+		a.AddParentSearch(".")
+		require.NoError(t, a.ReadInConfig())
+		var cfg testConfig
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "other", cfg.Log.Level)
+	})
+}
@@ -0,0 +1,72 @@
+package adder
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Resolver resolves ${scheme:arg} references in config values. The argument is
+// everything after the first colon. Register resolvers with
+// [Adder.RegisterResolver].
+type Resolver interface {
+	Resolve(arg string) (string, error)
+}
+
+// ResolverFunc adapts an ordinary function to the [Resolver] interface.
+type ResolverFunc func(arg string) (string, error)
+
+// Resolve calls f(arg).
+func (f ResolverFunc) Resolve(arg string) (string, error) { return f(arg) }
+
+// RegisterResolver calls [Adder.RegisterResolver] on the default instance.
+func RegisterResolver(scheme string, r Resolver) { defaultAdder.RegisterResolver(scheme, r) }
+
+// RegisterResolver makes ${scheme:arg} references in config values call r.
+// Registering an existing scheme replaces it. Resolvers run during
+// [Adder.ReadInConfig], so they must be registered before it is called.
+//
+// Every instance starts with these built-in schemes:
+//   - env: ${env:NAME} reads an environment variable and accepts the same
+//     modifiers as ${NAME}, e.g. ${env:PORT:-8080}
+//   - file: ${file:/run/secrets/db} reads a file and trims surrounding whitespace
+//   - base64: ${base64:aGVsbG8=} decodes standard base64
+func (a *Adder) RegisterResolver(scheme string, r Resolver) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.resolvers[scheme] = r
+}
+
+func (a *Adder) registerBuiltinResolvers() {
+	a.resolvers["env"] = ResolverFunc(a.expandEnvRef)
+	a.resolvers["file"] = ResolverFunc(resolveFile)
+	a.resolvers["base64"] = ResolverFunc(resolveBase64)
+}
+
+func resolveFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func resolveBase64(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return "", fmt.Errorf("invalid base64: %w", err)
+	}
+	return string(data), nil
+}
+
+// lookupResolver returns the resolver for a "scheme:arg" reference, if the
+// scheme is registered.
+func (a *Adder) lookupResolver(ref string) (Resolver, string, string, bool) {
+	scheme, arg, ok := strings.Cut(ref, ":")
+	if !ok {
+		return nil, "", "", false
+	}
+	r, ok := a.resolvers[scheme]
+	return r, scheme, arg, ok
+}
@@ -0,0 +1,83 @@
+package adder
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinResolvers(t *testing.T) {
+	type config struct {
+		Home     string
+		Port     string
+		Password string
+		Greeting string
+	}
+
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "db-password")
+	require.NoError(t, os.WriteFile(secret, []byte("s3cret\n"), 0o600))
+	t.Setenv("APP_HOME", "/srv/app")
+
+	a := newTestAdder(t, `
+home: ${env:APP_HOME}
+port: ${env:APP_PORT_12345:-8080}
+password: ${file:`+secret+`}
+greeting: ${base64:aGVsbG8gd29ybGQ=}
+`)
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "/srv/app", cfg.Home)
+	assert.Equal(t, "8080", cfg.Port)
+	assert.Equal(t, "s3cret", cfg.Password)
+	assert.Equal(t, "hello world", cfg.Greeting)
+}
+
+func TestResolverErrors(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		_, err := New().expandEnvBraceOnly("${file:/nonexistent/secret}")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "file: open /nonexistent/secret")
+	})
+
+	t.Run("invalid base64", func(t *testing.T) {
+		_, err := New().expandEnvBraceOnly("${base64:not base64!}")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "base64: invalid base64")
+	})
+}
+
+func TestRegisterResolver(t *testing.T) {
+	secrets := map[string]string{"db/password": "hunter2"}
+
+	a := New()
+	a.RegisterResolver("vault", ResolverFunc(func(arg string) (string, error) {
+		v, ok := secrets[arg]
+		if !ok {
+			return "", errors.New("secret not found")
+		}
+		return v, nil
+	}))
+	a.RegisterResolver("upper", ResolverFunc(func(arg string) (string, error) {
+		return strings.ToUpper(arg), nil
+	}))
+
+	got, err := a.expandEnvBraceOnly("${vault:db/password} ${upper:abc}")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2 ABC", got)
+
+	_, err = a.expandEnvBraceOnly("${vault:db/missing}")
+	require.Error(t, err)
+	assert.EqualError(t, err, "vault: secret not found")
+
+	// Unregistered schemes fall through to the environment, so modifiers still work.
+	got, err = a.expandEnvBraceOnly("${UNSET_VAR_12345:-fallback}")
+	require.NoError(t, err)
+	assert.Equal(t, "fallback", got)
+}
@@ -0,0 +1,38 @@
+package adder
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ReloadOnSignal calls [Adder.ReloadOnSignal] on the default instance.
+func ReloadOnSignal(ctx context.Context, sigs ...os.Signal) {
+	defaultAdder.ReloadOnSignal(ctx, sigs...)
+}
+
+// ReloadOnSignal reloads the configuration, including all merged layers,
+// whenever the process receives one of sigs, until ctx is done. With no
+// signals it listens for SIGHUP. Each reload notifies the callbacks registered
+// with [Adder.OnConfigChange] and [Adder.OnKeyChange], just like file
+// watching; a failed reload keeps the current values.
+func (a *Adder) ReloadOnSignal(ctx context.Context, sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-ch:
+				a.reload(Event{Signal: sig})
+			}
+		}
+	}()
+}
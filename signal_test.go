@@ -0,0 +1,64 @@
+//go:build unix
+
+package adder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSignalAdder(t *testing.T, path string) (*Adder, <-chan Event) {
+	t.Helper()
+	a := New()
+	a.SetConfigFile(path)
+	require.NoError(t, a.ReadInConfig())
+
+	events := make(chan Event, 10)
+	a.OnConfigChange(func(e Event) { events <- e })
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	a.ReloadOnSignal(ctx, syscall.SIGUSR1)
+	return a, events
+}
+
+func TestReloadOnSignal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "application.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: info\n"), 0o644))
+
+	a, events := newSignalAdder(t, path)
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: debug\n"), 0o644))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+	e := waitEvent(t, events)
+	require.NoError(t, e.Err)
+	assert.Equal(t, syscall.SIGUSR1, e.Signal)
+	assert.Empty(t, e.Path)
+	assert.Equal(t, []Change{{Key: "log.level", Old: "info", New: "debug", Source: path}}, e.Changes)
+
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "debug", cfg.Log.Level)
+}
+
+func TestReloadOnSignalKeepsValuesOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "application.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: info\n"), 0o644))
+
+	a, events := newSignalAdder(t, path)
+	require.NoError(t, os.WriteFile(path, []byte("log: [unclosed\n"), 0o644))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+	e := waitEvent(t, events)
+	require.Error(t, e.Err)
+	assert.Empty(t, e.Changes)
+
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "info", cfg.Log.Level)
+}
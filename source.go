@@ -0,0 +1,187 @@
+package adder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configSource is an extra config layer merged on top of the main config file
+// by [Adder.ReadInConfig].
+type configSource interface {
+	load(a *Adder) (map[string]any, error)
+}
+
+// AddConfigDir calls [Adder.AddConfigDir] on the default instance.
+func AddConfigDir(path string) { defaultAdder.AddConfigDir(path) }
+
+// AddConfigDir adds a conf.d-style directory whose files are loaded by
+// [Adder.ReadInConfig]. Every supported file in the directory (e.g.
+// 10-base.yaml, 20-tls.yaml) is read in lexical order and deep-merged, so later
+// files override earlier ones. Hidden files, subdirectories and files with other
+// extensions are ignored. Maps are merged key by key; lists are replaced.
+func (a *Adder) AddConfigDir(path string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sources = append(a.sources, configDir{path: path})
+}
+
+type configDir struct {
+	path string
+}
+
+func (d configDir) load(a *Adder) (map[string]any, error) {
+	a.trackFile(d.path)
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config dir: %w", err)
+	}
+
+	values := make(map[string]any)
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+		if !supportedConfigType(ext) {
+			continue
+		}
+		path := filepath.Join(d.path, name)
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+
+		fileValues, err := a.readConfigFile(path, ext)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		mergeValues(values, fileValues)
+	}
+	return values, nil
+}
+
+// AddKeyPerFileDir calls [Adder.AddKeyPerFileDir] on the default instance.
+func AddKeyPerFileDir(path string) { defaultAdder.AddKeyPerFileDir(path) }
+
+// AddKeyPerFileDir adds a directory where each file holds a single config value,
+// as produced by mounting a Kubernetes ConfigMap or Secret as a volume. The file
+// name is the key and its contents, minus trailing newlines, are the value: both
+// "server.port" and "server/port" map to the key server.port. Hidden entries are
+// skipped, which covers the "..data" symlink indirection Kubernetes uses, while
+// the visible symlinks pointing into it are followed. Values are used
+// byte-for-byte: ${VAR}, ${key.path} and $${...} in them are not interpreted.
+//
+// Like [Adder.AddConfigDir], the directory is read by [Adder.ReadInConfig] and
+// merged on top of earlier layers in the order sources were added.
+func (a *Adder) AddKeyPerFileDir(path string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sources = append(a.sources, keyPerFileDir{path: path})
+}
+
+type keyPerFileDir struct {
+	path string
+}
+
+func (d keyPerFileDir) load(a *Adder) (map[string]any, error) {
+	values := make(map[string]any)
+	if err := a.readKeyPerFileDir(d.path, nil, values, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (a *Adder) readKeyPerFileDir(dir string, prefix []string, values map[string]any, visited map[string]bool) error {
+	// Guard against symlinked directory loops.
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read config dir: %w", err)
+	}
+	if visited[resolved] {
+		return nil
+	}
+	visited[resolved] = true
+	a.trackFile(dir)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read config dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil {
+			// Dangling symlink, e.g. mid-way through a ConfigMap update.
+			continue
+		}
+
+		key := append(prefix[:len(prefix):len(prefix)], strings.Split(name, ".")...)
+		if info.IsDir() {
+			if err := a.readKeyPerFileDir(path, key, values, visited); err != nil {
+				return err
+			}
+			continue
+		}
+
+		a.trackFile(path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read config value: %w", err)
+		}
+		value := strings.TrimRight(string(data), "\r\n")
+		setPath(values, key, value)
+		a.loadedOrigins[strings.ToLower(strings.Join(key, "."))] = Position{File: path}
+	}
+	return nil
+}
+
+// setPath stores value in m under the nested key path, creating intermediate
+// maps as needed and replacing any non-map value in the way.
+func setPath(m map[string]any, path []string, value any) {
+	for _, part := range path[:len(path)-1] {
+		next, ok := m[part].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			m[part] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = value
+}
+
+func supportedConfigType(configType string) bool {
+	switch configType {
+	case "yaml", "yml":
+		return true
+	}
+	return false
+}
+
+// mergeValues deep-merges src into dst. Nested maps are merged key by key,
+// matching keys case-insensitively; any other value in src replaces the one in dst.
+func mergeValues(dst, src map[string]any) {
+	for k, v := range src {
+		key := k
+		for existing := range dst {
+			if strings.EqualFold(existing, k) {
+				key = existing
+				break
+			}
+		}
+
+		srcMap, srcIsMap := v.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeValues(dstMap, srcMap)
+			continue
+		}
+		dst[key] = v
+	}
+}
@@ -0,0 +1,232 @@
+package adder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddConfigDir(t *testing.T) {
+	type tls struct {
+		Enabled bool
+		Cert    string
+	}
+	type server struct {
+		Host string
+		Port int
+		TLS  tls `mapstructure:"tls"`
+	}
+	type config struct {
+		Server  server
+		Origins []string
+		Log     testLogConfig
+	}
+
+	base := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(base, "application.yaml"), []byte(`
+server:
+  host: localhost
+  port: 8080
+origins: [a, b]
+log:
+  level: info
+`), 0o644))
+
+	confd := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(confd, "20-tls.yaml"), []byte(`
+server:
+  port: 8443
+  tls:
+    enabled: true
+    cert: /etc/tls/cert.pem
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(confd, "10-base.yml"), []byte(`
+server:
+  host: 0.0.0.0
+  port: 9090
+origins: [c]
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(confd, "30-ignored.txt"), []byte("server: {host: nope}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(confd, ".hidden.yaml"), []byte("server: {host: nope}\n"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(confd, "40-dir.yaml"), 0o755))
+
+	a := New()
+	a.SetConfigName("application")
+	a.SetConfigType("yaml")
+	a.AddConfigPath(base)
+	a.AddConfigDir(confd)
+	require.NoError(t, a.ReadInConfig())
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, server{
+		Host: "0.0.0.0",
+		Port: 8443,
+		TLS:  tls{Enabled: true, Cert: "/etc/tls/cert.pem"},
+	}, cfg.Server)
+	assert.Equal(t, []string{"c"}, cfg.Origins)
+	assert.Equal(t, "info", cfg.Log.Level)
+}
+
+func TestAddConfigDirWithoutConfigFile(t *testing.T) {
+	confd := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(confd, "00-log.yaml"), []byte("log:\n  level: ${LOG_LEVEL:-warn}\n"), 0o644))
+
+	a := New()
+	a.AddConfigDir(confd)
+	require.NoError(t, a.ReadInConfig())
+
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "warn", cfg.Log.Level)
+}
+
+func TestAddConfigDirErrors(t *testing.T) {
+	t.Run("missing dir", func(t *testing.T) {
+		a := New()
+		a.AddConfigDir(filepath.Join(t.TempDir(), "missing"))
+		err := a.ReadInConfig()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read config dir")
+	})
+
+	t.Run("invalid fragment names the file", func(t *testing.T) {
+		confd := t.TempDir()
+		path := filepath.Join(confd, "10-bad.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("server: [\n"), 0o644))
+
+		a := New()
+		a.AddConfigDir(confd)
+		err := a.ReadInConfig()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), path+": failed to parse yaml")
+	})
+}
+
+func TestReadInConfigReplacesPreviousValues(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "application.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: debug\nhttp:\n  port: 8080\n"), 0o644))
+
+	a := New()
+	a.SetConfigFile(path)
+	require.NoError(t, a.ReadInConfig())
+
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: warn\n"), 0o644))
+	require.NoError(t, a.ReadInConfig())
+
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "warn", cfg.Log.Level)
+	assert.Zero(t, cfg.Http.Port)
+}
+
+func TestMergeValues(t *testing.T) {
+	dst := map[string]any{
+		"Server": map[string]any{"host": "a", "port": 1},
+		"list":   []any{1, 2},
+	}
+	mergeValues(dst, map[string]any{
+		"server": map[string]any{"port": 2, "tls": true},
+		"list":   []any{3},
+		"new":    "x",
+	})
+
+	assert.Equal(t, map[string]any{
+		"Server": map[string]any{"host": "a", "port": 2, "tls": true},
+		"list":   []any{3},
+		"new":    "x",
+	}, dst)
+}
+
+func TestAddKeyPerFileDir(t *testing.T) {
+	type server struct {
+		Host    string
+		Port    int
+		Debug   bool
+		Timeout time.Duration
+	}
+	type config struct {
+		Server server
+		Labels map[string]string
+		Log    testLogConfig
+	}
+
+	// Mimic the layout of a Kubernetes ConfigMap volume: the real files live in
+	// a timestamped directory, "..data" points at it, and each key is a symlink
+	// through "..data".
+	dir := t.TempDir()
+	data := filepath.Join(dir, "..2024_01_01_00_00_00.000000000")
+	require.NoError(t, os.Mkdir(data, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(data, "server.port"), []byte("9090\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(data, "server.debug"), []byte("true"), 0o644))
+	require.NoError(t, os.Symlink(filepath.Base(data), filepath.Join(dir, "..data")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "server.port"), filepath.Join(dir, "server.port")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "server.debug"), filepath.Join(dir, "server.debug")))
+
+	// Nested directories also map to keys.
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "server"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "server", "timeout"), []byte("5s\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "labels"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "labels", "team"), []byte("payments\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), []byte("ignored"), 0o644))
+
+	base := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(base, "application.yaml"), []byte(`
+server:
+  host: localhost
+  port: 8080
+labels:
+  env: prod
+log:
+  level: info
+`), 0o644))
+
+	a := New()
+	a.SetConfigFile(filepath.Join(base, "application.yaml"))
+	a.AddKeyPerFileDir(dir)
+	require.NoError(t, a.ReadInConfig())
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, server{Host: "localhost", Port: 9090, Debug: true, Timeout: 5 * time.Second}, cfg.Server)
+	assert.Equal(t, map[string]string{"env": "prod", "team": "payments"}, cfg.Labels)
+	assert.Equal(t, "info", cfg.Log.Level)
+}
+
+func TestAddKeyPerFileDirValuesAreNotExpanded(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"log.level":    "${LOG_LEVEL:-debug}\n",
+		"db.url":       "pa$${x}ss\n",
+		"db.password":  "${log.level}",
+		"http.address": "${db.url}",
+	})
+
+	for _, interpolation := range []bool{true, false} {
+		a := New()
+		a.SetInterpolation(interpolation)
+		a.AddKeyPerFileDir(dir)
+		require.NoError(t, a.ReadInConfig())
+
+		var cfg struct {
+			Log testLogConfig
+			Db  struct {
+				URL      string `mapstructure:"url"`
+				Password string
+			}
+			Http struct {
+				Address string
+			}
+		}
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "${LOG_LEVEL:-debug}", cfg.Log.Level)
+		assert.Equal(t, "pa$${x}ss", cfg.Db.URL)
+		assert.Equal(t, "${log.level}", cfg.Db.Password)
+		assert.Equal(t, "${db.url}", cfg.Http.Address)
+	}
+}
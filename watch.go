@@ -0,0 +1,181 @@
+package adder
+
+import (
+	"os"
+	"slices"
+	"time"
+)
+
+const defaultWatchInterval = time.Second
+
+// Event describes a configuration reload.
+type Event struct {
+	// Path is the file or directory whose change triggered the reload. It is
+	// empty for reloads triggered by a signal.
+	Path string
+	// Signal is the signal that triggered the reload, if any (see
+	// [Adder.ReloadOnSignal]).
+	Signal os.Signal
+	// Err is set when the reload failed. The previously loaded values are kept.
+	Err error
+	// Changes lists the keys whose values differ from before the reload,
+	// sorted by key. It is empty when the reload failed.
+	Changes []Change
+}
+
+// OnConfigChange calls [Adder.OnConfigChange] on the default instance.
+func OnConfigChange(fn func(Event)) { defaultAdder.OnConfigChange(fn) }
+
+// OnConfigChange registers a callback that runs after every reload triggered
+// by [Adder.WatchConfig] or [Adder.ReloadOnSignal], including failed ones (see
+// [Event.Err]). Callbacks run in registration order on the watcher goroutine
+// and may call [Adder.Unmarshal] to pick up the new values.
+func (a *Adder) OnConfigChange(fn func(Event)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onChange = append(a.onChange, fn)
+}
+
+// SetWatchInterval calls [Adder.SetWatchInterval] on the default instance.
+func SetWatchInterval(d time.Duration) { defaultAdder.SetWatchInterval(d) }
+
+// SetWatchInterval sets how often [Adder.WatchConfig] checks for changes.
+// The default is one second. It takes effect the next time watching starts.
+func (a *Adder) SetWatchInterval(d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.watchInterval = d
+}
+
+// WatchConfig calls [Adder.WatchConfig] on the default instance.
+func WatchConfig() { defaultAdder.WatchConfig() }
+
+// WatchConfig starts watching every file read by the last successful
+// [Adder.ReadInConfig], including included files and config directories.
+// When one changes, the configuration is reloaded with [Adder.ReadInConfig]
+// and the [Adder.OnConfigChange] callbacks are notified.
+//
+// Files are polled, which handles editors that save by writing a new file and
+// renaming it over the old one, as well as the symlink swap Kubernetes performs
+// when a mounted ConfigMap changes. Calling WatchConfig again restarts the watcher.
+func (a *Adder) WatchConfig() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stopWatch != nil {
+		close(a.stopWatch)
+	}
+	interval := a.watchInterval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	a.stopWatch = make(chan struct{})
+	go a.watch(a.stopWatch, interval)
+}
+
+// StopWatching calls [Adder.StopWatching] on the default instance.
+func StopWatching() { defaultAdder.StopWatching() }
+
+// StopWatching stops the watcher started by [Adder.WatchConfig], if any.
+func (a *Adder) StopWatching() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stopWatch != nil {
+		close(a.stopWatch)
+		a.stopWatch = nil
+	}
+}
+
+// watch polls the files recorded by the last load. Their stamps are taken when
+// load reads them, so changes made since then are noticed.
+func (a *Adder) watch(stop <-chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		a.mu.RLock()
+		stamps := a.watchedFiles
+		a.mu.RUnlock()
+
+		if changed, ok := firstChanged(stamps); ok {
+			a.reload(Event{Path: changed})
+		}
+	}
+}
+
+// reload re-reads the configuration and notifies the change callbacks. On
+// failure the current values are left untouched.
+func (a *Adder) reload(e Event) {
+	a.mu.RLock()
+	optional := a.loadOptional
+	a.mu.RUnlock()
+
+	e.Changes, e.Err = a.load(optional)
+	a.notify(e)
+}
+
+func (a *Adder) notify(e Event) {
+	a.mu.RLock()
+	callbacks := slices.Clone(a.onChange)
+	keyHandlers := slices.Clone(a.onKeyChange)
+	a.mu.RUnlock()
+
+	for _, fn := range callbacks {
+		fn(e)
+	}
+	for _, h := range keyHandlers {
+		if changes := e.ChangesUnder(h.prefix); len(changes) > 0 {
+			h.fn(changes)
+		}
+	}
+}
+
+type fileStamp struct {
+	path string
+	info os.FileInfo // nil if the file could not be stat'ed
+}
+
+func statFile(path string) fileStamp {
+	stamp := fileStamp{path: path}
+	// Stat follows symlinks, so a swapped link target shows up as a different file.
+	if info, err := os.Stat(path); err == nil {
+		stamp.info = info
+	}
+	return stamp
+}
+
+// mergeStamps returns the files read by a failed load together with the
+// previously watched files it did not get to. Those are stamped again, so that
+// the change that triggered the failed reload is not reported twice.
+func mergeStamps(attempt, previous []fileStamp) []fileStamp {
+	merged := slices.Clone(attempt)
+	for _, old := range previous {
+		if !slices.ContainsFunc(attempt, func(s fileStamp) bool { return s.path == old.path }) {
+			merged = append(merged, statFile(old.path))
+		}
+	}
+	return merged
+}
+
+// firstChanged returns the first file that differs from its stamp. Files that
+// are currently missing are skipped, since an atomic save briefly removes them.
+func firstChanged(stamps []fileStamp) (string, bool) {
+	for _, stamp := range stamps {
+		info, err := os.Stat(stamp.path)
+		if err != nil {
+			continue
+		}
+		old := stamp.info
+		if old == nil || !os.SameFile(old, info) || !old.ModTime().Equal(info.ModTime()) || old.Size() != info.Size() {
+			return stamp.path, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,222 @@
+package adder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWatchedAdder(t *testing.T, path string) (*Adder, <-chan Event) {
+	t.Helper()
+	a := New()
+	a.SetConfigFile(path)
+	require.NoError(t, a.ReadInConfig())
+
+	events := make(chan Event, 10)
+	a.OnConfigChange(func(e Event) { events <- e })
+	a.SetWatchInterval(10 * time.Millisecond)
+	a.WatchConfig()
+	t.Cleanup(a.StopWatching)
+	return a, events
+}
+
+func waitEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for config change event")
+		return Event{}
+	}
+}
+
+func TestWatchConfigInPlaceWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "application.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: info\n"), 0o644))
+
+	a, events := newWatchedAdder(t, path)
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: debug\n"), 0o644))
+
+	e := waitEvent(t, events)
+	require.NoError(t, e.Err)
+	abs, _ := filepath.Abs(path)
+	assert.Equal(t, abs, e.Path)
+
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "debug", cfg.Log.Level)
+}
+
+func TestWatchConfigSeesWritesBeforeWatching(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "application.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: info\n"), 0o644))
+
+	a := New()
+	a.SetConfigFile(path)
+	require.NoError(t, a.ReadInConfig())
+
+	// Written after the load but before watching starts.
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: debug\n"), 0o644))
+
+	events := make(chan Event, 10)
+	a.OnConfigChange(func(e Event) { events <- e })
+	a.SetWatchInterval(10 * time.Millisecond)
+	a.WatchConfig()
+	t.Cleanup(a.StopWatching)
+
+	require.NoError(t, waitEvent(t, events).Err)
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "debug", cfg.Log.Level)
+}
+
+func TestWatchConfigAtomicRename(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "application.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: info\n"), 0o644))
+
+	a, events := newWatchedAdder(t, path)
+	tmp := filepath.Join(dir, ".application.yaml.swp")
+	require.NoError(t, os.WriteFile(tmp, []byte("log:\n  level: warn\n"), 0o644))
+	require.NoError(t, os.Rename(tmp, path))
+
+	require.NoError(t, waitEvent(t, events).Err)
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "warn", cfg.Log.Level)
+}
+
+func TestWatchConfigSymlinkSwap(t *testing.T) {
+	// Kubernetes layout: application.yaml -> ..data/application.yaml, where
+	// ..data is a symlink that is atomically swapped to a new directory.
+	dir := t.TempDir()
+	v1 := filepath.Join(dir, "..v1")
+	v2 := filepath.Join(dir, "..v2")
+	require.NoError(t, os.Mkdir(v1, 0o755))
+	require.NoError(t, os.Mkdir(v2, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(v1, "application.yaml"), []byte("log:\n  level: info\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(v2, "application.yaml"), []byte("log:\n  level: error\n"), 0o644))
+	require.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	path := filepath.Join(dir, "application.yaml")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "application.yaml"), path))
+
+	a, events := newWatchedAdder(t, path)
+	tmpLink := filepath.Join(dir, "..data_tmp")
+	require.NoError(t, os.Symlink("..v2", tmpLink))
+	require.NoError(t, os.Rename(tmpLink, filepath.Join(dir, "..data")))
+
+	require.NoError(t, waitEvent(t, events).Err)
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "error", cfg.Log.Level)
+}
+
+func TestWatchConfigWatchesIncludedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"application.yaml": "log: !include log.yaml\n",
+		"log.yaml":         "level: info\n",
+	})
+
+	a, events := newWatchedAdder(t, filepath.Join(dir, "application.yaml"))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "log.yaml"), []byte("level: trace\n"), 0o644))
+
+	e := waitEvent(t, events)
+	require.NoError(t, e.Err)
+	assert.Equal(t, filepath.Join(dir, "log.yaml"), e.Path)
+
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "trace", cfg.Log.Level)
+}
+
+func TestWatchConfigFailedReloadKeepsValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "application.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: info\n"), 0o644))
+
+	a, events := newWatchedAdder(t, path)
+	require.NoError(t, os.WriteFile(path, []byte("log: [unterminated\n"), 0o644))
+
+	e := waitEvent(t, events)
+	require.Error(t, e.Err)
+	assert.Contains(t, e.Err.Error(), "failed to parse yaml")
+
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "info", cfg.Log.Level)
+
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: debug\n"), 0o644))
+	require.NoError(t, waitEvent(t, events).Err)
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "debug", cfg.Log.Level)
+}
+
+func TestStopWatching(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "application.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: info\n"), 0o644))
+
+	a, events := newWatchedAdder(t, path)
+	a.StopWatching()
+	time.Sleep(30 * time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: debug\n"), 0o644))
+
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event after StopWatching: %+v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWatchConfigOptionalFileCreated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "application.yaml")
+
+	a := New()
+	a.SetConfigFile(path)
+	a.SetConfigOptional(true)
+	require.NoError(t, a.ReadInConfig())
+
+	events := make(chan Event, 10)
+	a.OnConfigChange(func(e Event) { events <- e })
+	a.SetWatchInterval(10 * time.Millisecond)
+	a.WatchConfig()
+	t.Cleanup(a.StopWatching)
+
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: debug\n"), 0o644))
+	e := waitEvent(t, events)
+	require.NoError(t, e.Err)
+
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "debug", cfg.Log.Level)
+}
+
+func TestWatchConfigAfterReadInConfigOptional(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"conf.d/log.yaml": "log:\n  level: info\n"})
+
+	a := New()
+	a.SetConfigName("application")
+	a.SetConfigType("yaml")
+	a.AddConfigPath(dir)
+	a.AddConfigDir(filepath.Join(dir, "conf.d"))
+	require.NoError(t, a.ReadInConfigOptional())
+
+	events := make(chan Event, 10)
+	a.OnConfigChange(func(e Event) { events <- e })
+	a.SetWatchInterval(10 * time.Millisecond)
+	a.WatchConfig()
+	t.Cleanup(a.StopWatching)
+
+	writeFiles(t, dir, map[string]string{"conf.d/log.yaml": "log:\n  level: debug\n"})
+	e := waitEvent(t, events)
+	require.NoError(t, e.Err)
+
+	var cfg testConfig
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, "debug", cfg.Log.Level)
+}
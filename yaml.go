@@ -0,0 +1,266 @@
+package adder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Custom YAML tags resolved while loading config files.
+const (
+	includeTag    = "!include"
+	includeDirTag = "!include_dir"
+	envTag        = "!env"
+)
+
+// loadYAMLDocs reads every document in a YAML file into a node tree and
+// resolves the custom tags:
+//
+//	!include other.yaml      splices in another file, relative to the current one
+//	!include_dir fragments   a mapping of every YAML file in a directory, keyed by
+//	                         file name without extension
+//	!env NAME                the value of an environment variable, typed as if it
+//	                         had been written in the file (null when unset);
+//	                         ${...} in the value is not expanded
+//
+// Included files contribute their first document only. stack holds the files
+// currently being loaded and is used to detect include cycles.
+func (a *Adder) loadYAMLDocs(path string, stack []string) ([]*yaml.Node, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range stack {
+		if p == abs {
+			chain := append(stack[i:len(stack):len(stack)], abs)
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	a.trackFile(abs)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Legacy mode: expand ${VAR} references in the raw config (bare $VAR is intentionally not expanded)
+	if !a.noInterpolation && a.rawInterpolation {
+		expanded, err := a.expandEnvBraceOnly(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand config file: %w", err)
+		}
+		data = []byte(expanded)
+	}
+
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, &ParseError{Path: path, Err: err}
+		}
+		if err := a.resolveTags(&doc, filepath.Dir(abs), append(stack[:len(stack):len(stack)], abs)); err != nil {
+			return nil, err
+		}
+		docs = append(docs, &doc)
+	}
+	return docs, nil
+}
+
+func (a *Adder) resolveTags(node *yaml.Node, dir string, stack []string) error {
+	if node.Kind != yaml.ScalarNode {
+		for _, child := range node.Content {
+			if err := a.resolveTags(child, dir, stack); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch node.Tag {
+	case includeTag:
+		path := resolveIncludePath(dir, node.Value)
+		docs, err := a.loadYAMLDocs(path, stack)
+		if err != nil {
+			return fmt.Errorf("line %d: %s %s: %w", node.Line, includeTag, node.Value, err)
+		}
+		*node = *firstDocumentRoot(docs)
+		a.loadedNodeFiles[node] = path
+	case includeDirTag:
+		m, err := a.loadYAMLDir(resolveIncludePath(dir, node.Value), stack)
+		if err != nil {
+			return fmt.Errorf("line %d: %s %s: %w", node.Line, includeDirTag, node.Value, err)
+		}
+		*node = *m
+	case envTag:
+		v, ok := a.lookupExpandEnv(strings.TrimSpace(node.Value))
+		if !ok {
+			*node = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Line: node.Line, Column: node.Column}
+			return nil
+		}
+		if !a.noInterpolation {
+			// The value is expanded like the rest of the file; keep it literal.
+			v = escapeRefs(v, false)
+		}
+		// An empty tag lets the decoder resolve the type (int, bool, ...) from the value.
+		*node = yaml.Node{Kind: yaml.ScalarNode, Value: v, Line: node.Line, Column: node.Column}
+	}
+	return nil
+}
+
+// loadYAMLDir builds a mapping node from every YAML file in dir, keyed by file
+// name without extension. os.ReadDir returns entries in lexical order.
+func (a *Adder) loadYAMLDir(dir string, stack []string) (*yaml.Node, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config dir: %w", err)
+	}
+
+	m := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		if strings.HasPrefix(name, ".") || !supportedConfigType(strings.ToLower(strings.TrimPrefix(ext, "."))) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+
+		docs, err := a.loadYAMLDocs(path, stack)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: strings.TrimSuffix(name, ext)}
+		root := firstDocumentRoot(docs)
+		a.loadedNodeFiles[root] = path
+		m.Content = append(m.Content, key, root)
+	}
+	return m, nil
+}
+
+func resolveIncludePath(dir, path string) string {
+	path = strings.TrimSpace(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// firstDocumentRoot returns the top-level node of the first document, or a
+// null node when there is none.
+func firstDocumentRoot(docs []*yaml.Node) *yaml.Node {
+	if len(docs) > 0 && len(docs[0].Content) > 0 {
+		return docs[0].Content[0]
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+}
+
+// decodeDocuments decodes the documents of the config file at path according
+// to the document mode and selector, deep-merging them in order. The position
+// of every value is recorded for [Adder.Explain].
+func (a *Adder) decodeDocuments(docs []*yaml.Node, path string) (map[string]any, error) {
+	values := make(map[string]any)
+	for i, doc := range docs {
+		if i > 0 && a.documentMode == FirstDocument && a.docSelectorKey == "" {
+			break
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+
+		docValues := make(map[string]any)
+		if err := doc.Decode(&docValues); err != nil {
+			return nil, &ParseError{Path: path, Err: err}
+		}
+		if !a.selectsDocument(docValues) {
+			continue
+		}
+		mergeValues(values, docValues)
+		a.recordPositions("", doc, path)
+	}
+	return values, nil
+}
+
+// recordPositions records where every scalar under node was written, keyed by
+// its lower-cased dotted path. Nodes spliced in by !include and !include_dir
+// are attributed to the included file.
+func (a *Adder) recordPositions(prefix string, node *yaml.Node, file string) {
+	if f, ok := a.loadedNodeFiles[node]; ok {
+		file = f
+	}
+	join := func(k string) string {
+		if prefix == "" {
+			return strings.ToLower(k)
+		}
+		return prefix + "." + strings.ToLower(k)
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			a.recordPositions(prefix, child, file)
+		}
+	case yaml.AliasNode:
+		a.recordPositions(prefix, node.Alias, file)
+	case yaml.MappingNode:
+		// Merge keys (<<) come first so that explicit keys override them.
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value != "<<" {
+				continue
+			}
+			if merged := node.Content[i+1]; merged.Kind == yaml.SequenceNode {
+				for _, m := range merged.Content {
+					a.recordPositions(prefix, m, file)
+				}
+			} else {
+				a.recordPositions(prefix, merged, file)
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value != "<<" {
+				a.recordPositions(join(node.Content[i].Value), node.Content[i+1], file)
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			a.recordPositions(join(strconv.Itoa(i)), child, file)
+		}
+	case yaml.ScalarNode:
+		if prefix != "" {
+			a.loadedOrigins[prefix] = Position{File: file, Line: node.Line, Column: node.Column}
+		}
+	}
+}
+
+// selectsDocument reports whether a document applies under the selector set by
+// [Adder.SetDocumentSelector]. Documents without the discriminator key always apply.
+func (a *Adder) selectsDocument(values map[string]any) bool {
+	if a.docSelectorKey == "" {
+		return true
+	}
+	v, ok := lookupPath(values, a.docSelectorKey)
+	if !ok {
+		return true
+	}
+	if list, ok := v.([]any); ok {
+		for _, item := range list {
+			if fmt.Sprintf("%v", item) == a.docSelectorValue {
+				return true
+			}
+		}
+		return false
+	}
+	return fmt.Sprintf("%v", v) == a.docSelectorValue
+}
@@ -0,0 +1,254 @@
+package adder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestYAMLInclude(t *testing.T) {
+	type db struct {
+		Host string
+		Port int
+	}
+	type upstream struct {
+		URL string `mapstructure:"url"`
+	}
+	type config struct {
+		Db        db
+		Upstreams map[string]string
+		Servers   []upstream
+		Log       testLogConfig
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"application.yaml": `
+db: !include conf/db.yaml
+upstreams: !include_dir upstreams
+servers: !include conf/servers.yaml
+log:
+  level: ${LOG_LEVEL:-info}
+`,
+		"conf/db.yaml":           "host: ${DB_HOST}\nport: 5432\n",
+		"conf/servers.yaml":      "- !include ../servers/a.yaml\n- url: http://b\n",
+		"servers/a.yaml":         "url: http://a\n",
+		"upstreams/billing.yaml": "http://billing\n",
+		"upstreams/search.yml":   "http://search\n",
+		"upstreams/notes.txt":    "ignored\n",
+	})
+	t.Setenv("DB_HOST", "db.internal")
+
+	a := New()
+	a.SetConfigFile(filepath.Join(dir, "application.yaml"))
+	require.NoError(t, a.ReadInConfig())
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, db{Host: "db.internal", Port: 5432}, cfg.Db)
+	assert.Equal(t, map[string]string{"billing": "http://billing", "search": "http://search"}, cfg.Upstreams)
+	assert.Equal(t, []upstream{{URL: "http://a"}, {URL: "http://b"}}, cfg.Servers)
+	assert.Equal(t, "info", cfg.Log.Level)
+}
+
+func TestYAMLIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"application.yaml": "a: !include a.yaml\n",
+		"a.yaml":           "b: !include b.yaml\n",
+		"b.yaml":           "c: !include a.yaml\n",
+	})
+
+	a := New()
+	a.SetConfigFile(filepath.Join(dir, "application.yaml"))
+	err := a.ReadInConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "include cycle: "+
+		filepath.Join(dir, "a.yaml")+" -> "+filepath.Join(dir, "b.yaml")+" -> "+filepath.Join(dir, "a.yaml"))
+}
+
+func TestYAMLIncludeMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"application.yaml": "\ndb: !include missing.yaml\n"})
+
+	a := New()
+	a.SetConfigFile(filepath.Join(dir, "application.yaml"))
+	err := a.ReadInConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2: !include missing.yaml: failed to read config file")
+}
+
+func TestYAMLEnvTag(t *testing.T) {
+	type config struct {
+		Port    int
+		Debug   bool
+		Name    string
+		Missing string
+	}
+
+	t.Setenv("APP_PORT", "9090")
+	t.Setenv("APP_DEBUG", "true")
+	t.Setenv("APP_NAME", "svc")
+
+	a := newTestAdder(t, `
+port: !env APP_PORT
+debug: !env APP_DEBUG
+name: !env APP_NAME
+missing: !env APP_MISSING_12345
+`)
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, 9090, cfg.Port)
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, "svc", cfg.Name)
+	assert.Empty(t, cfg.Missing)
+
+	port, ok := lookupPath(a.configValues, "port")
+	require.True(t, ok)
+	assert.Equal(t, 9090, port)
+}
+
+func TestYAMLEnvTagValueIsLiteral(t *testing.T) {
+	t.Setenv("TOKVAL", "${server.host}")
+	t.Setenv("FAILVAL", "${NOPE_12345:?boom}")
+	t.Setenv("ESCVAL", "a$${b}")
+
+	for _, raw := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "application.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+server:
+  host: h
+token: !env TOKVAL
+fail: !env FAILVAL
+esc: !env ESCVAL
+`), 0o644))
+		a := New()
+		a.SetConfigFile(path)
+		a.SetRawInterpolation(raw)
+		require.NoError(t, a.ReadInConfig())
+
+		var cfg struct {
+			Token string
+			Fail  string
+			Esc   string
+		}
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, "${server.host}", cfg.Token)
+		assert.Equal(t, "${NOPE_12345:?boom}", cfg.Fail)
+		assert.Equal(t, "a$${b}", cfg.Esc)
+	}
+}
+
+func TestYAMLAnchorsStillWork(t *testing.T) {
+	type server struct {
+		Host string
+		Port int
+	}
+	type config struct {
+		Primary   server
+		Secondary server
+	}
+
+	a := newTestAdder(t, `
+defaults: &defaults
+  host: localhost
+  port: 8080
+primary: *defaults
+secondary:
+  <<: *defaults
+  port: 9090
+`)
+
+	var cfg config
+	require.NoError(t, a.Unmarshal(&cfg))
+	assert.Equal(t, server{Host: "localhost", Port: 8080}, cfg.Primary)
+	assert.Equal(t, server{Host: "localhost", Port: 9090}, cfg.Secondary)
+}
+
+func TestMultiDocumentYAML(t *testing.T) {
+	type config struct {
+		Profile string
+		Http    testHTTPConfig
+		Log     testLogConfig
+		Db      testDBConfig
+	}
+
+	content := `
+http:
+  port: 8080
+log:
+  level: info
+---
+profile: prod
+http:
+  port: 80
+db:
+  url: postgres://${DB_HOST:?only required in prod}/app
+---
+profile: [staging, qa]
+log:
+  level: debug
+---
+log:
+  level: warn
+`
+
+	t.Run("first document by default", func(t *testing.T) {
+		a := newTestAdder(t, content)
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, uint(8080), cfg.Http.Port)
+		assert.Equal(t, "info", cfg.Log.Level)
+		assert.Empty(t, cfg.Profile)
+	})
+
+	t.Run("merge all documents", func(t *testing.T) {
+		t.Setenv("DB_HOST", "db")
+		a := newTestAdder(t, content)
+		a.SetDocumentMode(MergeDocuments)
+		require.NoError(t, a.ReadInConfig())
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, uint(80), cfg.Http.Port)
+		assert.Equal(t, "warn", cfg.Log.Level)
+		assert.Equal(t, "postgres://db/app", cfg.Db.URL)
+	})
+
+	t.Run("select by discriminator", func(t *testing.T) {
+		a := newTestAdder(t, content)
+		a.SetDocumentSelector("profile", "qa")
+		require.NoError(t, a.ReadInConfig())
+
+		var cfg config
+		require.NoError(t, a.Unmarshal(&cfg))
+		assert.Equal(t, uint(8080), cfg.Http.Port)
+		assert.Equal(t, "warn", cfg.Log.Level)
+		assert.Empty(t, cfg.Db.URL)
+	})
+
+	t.Run("unselected documents are not interpolated", func(t *testing.T) {
+		a := newTestAdder(t, content)
+		a.SetDocumentSelector("profile", "prod")
+		err := a.ReadInConfig()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "only required in prod")
+
+		a.SetDocumentSelector("profile", "staging")
+		require.NoError(t, a.ReadInConfig())
+	})
+}